@@ -1,31 +1,26 @@
 package db
 
-// import (
-// 	"context"
-// 	"fmt"
-// 	"realworld-authentication/config"
+import (
+	"context"
+	"fmt"
+	"log"
+	"realworld-authentication/config/env"
 
-// 	"github.com/redis/go-redis/v9"
-// )
+	"github.com/redis/go-redis/v9"
+)
 
-// var (
-// 	RedisClient *redis.Client
-// )
+var (
+	RedisClient *redis.Client
+)
 
-// func ConnectRedis()
-// 	RedisClient = redis.NewClient(&redis.Options{
-// 		Addr: config.AppConfig.RedisUrl,
-// 	})
+func ConnectRedis() {
+	RedisClient = redis.NewClient(&redis.Options{
+		Addr: env.AppConfig.RedisUrl,
+	})
 
-// 	if _, err := RedisClient.Ping(context.TODO()).Result(); err != nil {
-// 		panic(err)
-// 	}
+	if _, err := RedisClient.Ping(context.TODO()).Result(); err != nil {
+		log.Fatal(err)
+	}
 
-// 	err := RedisClient.Set(context.TODO(), "test", "This is response from redis server", 0).Err()
-// 	if err != nil {
-// 		panic(err)
-// 	}
-
-// 	fmt.Println("Redis client connected successfully")
-// }
-//
+	fmt.Println("Connect to Redis successfully")
+}
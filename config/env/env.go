@@ -2,6 +2,7 @@ package env
 
 import (
 	"fmt"
+	"realworld-authentication/model/enum"
 	"time"
 
 	"github.com/spf13/viper"
@@ -21,15 +22,121 @@ type appConfig struct {
 	RefreshTokenExpiredIn time.Duration `mapstructure:"refresh_token_expired_in"`
 	RefreshTokenMaxAge    int64         `mapstructure:"refresh_token_max_age"`
 
+	// access token signing information, the audience is the "aud" claim of the access tokens accepted by this api
+	AccessTokenSigningMethod  string `mapstructure:"access_token_signing_method"`
+	AccessTokenPrivateKeyPath string `mapstructure:"access_token_private_key_path"`
+	AccessTokenAudience       string `mapstructure:"access_token_audience"`
+
+	// signing key rotation information
+	SigningKeyEncryptionKey string        `mapstructure:"signing_key_encryption_key"`
+	SigningKeyRefreshIn     time.Duration `mapstructure:"signing_key_refresh_in"`
+
+	// two factor information
+	TwoFactorIssuer             string        `mapstructure:"two_factor_issuer"`
+	TwoFactorEncryptionKey      string        `mapstructure:"two_factor_encryption_key"`
+	TwoFactorSkew               int64         `mapstructure:"two_factor_skew"`
+	TwoFactorChallengeKey       string        `mapstructure:"two_factor_challenge_key"`
+	TwoFactorChallengeExpiredIn time.Duration `mapstructure:"two_factor_challenge_expired_in"`
+	TwoFactorRecoveryCodeCount  int           `mapstructure:"two_factor_recovery_code_count"`
+
+	// openid connect provider information
+	OIDCIssuer                     string        `mapstructure:"oidc_issuer"`
+	OIDCLoginUrl                   string        `mapstructure:"oidc_login_url"`
+	OIDCAuthorizationCodeExpiredIn time.Duration `mapstructure:"oidc_authorization_code_expired_in"`
+
+	// reset password information
+	ResetPasswordUrl            string        `mapstructure:"reset_password_url"`
+	ResetPasswordTokenExpiredIn time.Duration `mapstructure:"reset_password_token_expired_in"`
+
+	// email verification information
+	EmailVerificationKey       string        `mapstructure:"email_verification_key"`
+	EmailVerificationExpiredIn time.Duration `mapstructure:"email_verification_expired_in"`
+	EmailVerificationUrl       string        `mapstructure:"email_verification_url"`
+	EmailVerificationResendIn  time.Duration `mapstructure:"email_verification_resend_in"`
+	RejectUnverifiedLogin      bool          `mapstructure:"reject_unverified_login"`
+
+	// webauthn information, origins are the exact origins of the web apps allowed to run the ceremonies
+	WebAuthnRPID               string        `mapstructure:"webauthn_rp_id"`
+	WebAuthnRPName             string        `mapstructure:"webauthn_rp_name"`
+	WebAuthnOrigins            []string      `mapstructure:"webauthn_origins"`
+	WebAuthnChallengeExpiredIn time.Duration `mapstructure:"webauthn_challenge_expired_in"`
+
+	// magic link information
+	MagicLinkEnabled   bool          `mapstructure:"magic_link_enabled"`
+	MagicLinkUrl       string        `mapstructure:"magic_link_url"`
+	MagicLinkExpiredIn time.Duration `mapstructure:"magic_link_expired_in"`
+	MagicLinkResendIn  time.Duration `mapstructure:"magic_link_resend_in"`
+
+	// password policy information
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+
+	// password history information, a new password cannot be any of the last count passwords and 0 allows reuse
+	PasswordHistoryCount int `mapstructure:"password_history_count"`
+
+	// password expiry information, passwords older than expired in must be changed at next login and 0 turns expiry
+	// off, the password change token given instead of the session tokens only authorizes changing the password
+	PasswordExpiredIn            time.Duration `mapstructure:"password_expired_in"`
+	PasswordChangeTokenKey       string        `mapstructure:"password_change_token_key"`
+	PasswordChangeTokenExpiredIn time.Duration `mapstructure:"password_change_token_expired_in"`
+
+	// breached password information, the dataset is a directory of HIBP range files and an empty directory
+	// turns the check off, passwords seen at least min count times are rejected or only reported as a warning
+	BreachedPasswordDatasetDir string                           `mapstructure:"breached_password_dataset_dir"`
+	BreachedPasswordAction     enum.BreachedPasswordActionValue `mapstructure:"breached_password_action"`
+	BreachedPasswordMinCount   int64                            `mapstructure:"breached_password_min_count"`
+
+	// login lockout information, back-off durations are in seconds and a zero threshold disables the lock
+	LoginLockoutThreshold   int64         `mapstructure:"login_lockout_threshold"`
+	LoginIPLockoutThreshold int64         `mapstructure:"login_ip_lockout_threshold"`
+	LoginLockoutDuration    time.Duration `mapstructure:"login_lockout_duration"`
+	LoginFailureWindow      time.Duration `mapstructure:"login_failure_window"`
+	LoginBackoffBase        time.Duration `mapstructure:"login_backoff_base"`
+	LoginBackoffMax         time.Duration `mapstructure:"login_backoff_max"`
+
+	// client ip information, X-Forwarded-For is only read when the request comes from one of the trusted proxy
+	// ranges, the peer address is used otherwise
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	// rate limit information, policies are keyed by the name used when the middleware is added to a route
+	RateLimitEnabled  bool                             `mapstructure:"rate_limit_enabled"`
+	RateLimitDriver   enum.StorageDriverValue          `mapstructure:"rate_limit_driver"`
+	RateLimitPolicies map[string]RateLimitPolicyConfig `mapstructure:"rate_limit_policies"`
+
+	// notification information
+	NotificationDriver enum.NotificationDriverValue `mapstructure:"notification_driver"`
+	SMTPHost           string                       `mapstructure:"smtp_host"`
+	SMTPPort           int64                        `mapstructure:"smtp_port"`
+	SMTPUsername       string                       `mapstructure:"smtp_username"`
+	SMTPPassword       string                       `mapstructure:"smtp_password"`
+	SMTPFrom           string                       `mapstructure:"smtp_from"`
+
 	// redis information
 	ClientOrigin string `mapstructure:"client_origin"`
 	RedisUrl     string `mapstructure:"redis_url"`
 
+	// token revocation information
+	RevokedTokenDriver enum.StorageDriverValue `mapstructure:"revoked_token_driver"`
+
 	// google client info
 	GoogleOauthClientID    string `mapstructure:"google_oauth_client_id"`
 	GoogleOauthSecret      string `mapstructure:"google_oauth_secret"`
 	GoogleOauthRedirectUrl string `mapstructure:"google_oauth_redirect_url"`
 
+	// github client info
+	GithubOauthClientID    string `mapstructure:"github_oauth_client_id"`
+	GithubOauthSecret      string `mapstructure:"github_oauth_secret"`
+	GithubOauthRedirectUrl string `mapstructure:"github_oauth_redirect_url"`
+	GithubOauthBaseUrl     string `mapstructure:"github_oauth_base_url"`
+	GithubApiBaseUrl       string `mapstructure:"github_api_base_url"`
+
+	// openid connect login providers, keyed by the name used in /api/sessions/oauth/:provider
+	OAuthProviders map[string]OAuthProviderConfig `mapstructure:"oauth_providers"`
+
+	// social login state information, return paths ending with * allow every path under the prefix
+	OAuthStateKey       string        `mapstructure:"oauth_state_key"`
+	OAuthStateExpiredIn time.Duration `mapstructure:"oauth_state_expired_in"`
+	OAuthReturnPaths    []string      `mapstructure:"oauth_return_paths"`
+
 	// AWS Service
 	AWSRegion      string `mapstructure:"aws_region"`
 	AWSAccessKeyID string `mapstructure:"aws_access_key_id"`
@@ -37,6 +144,45 @@ type appConfig struct {
 	AWSBucketName  string `mapstructure:"aws_bucket_name"`
 }
 
+type OAuthProviderConfig struct {
+	Issuer       string            `mapstructure:"issuer"`
+	ClientID     string            `mapstructure:"client_id"`
+	ClientSecret string            `mapstructure:"client_secret"`
+	RedirectUrl  string            `mapstructure:"redirect_url"`
+	Scopes       []string          `mapstructure:"scopes"`
+	ClaimMapping OAuthClaimMapping `mapstructure:"claim_mapping"`
+}
+
+// PasswordPolicyConfig lists the rules every new password must meet, MinStrengthScore is a zxcvbn-style score
+// from 0 to 4 and 0 turns the estimation off
+type PasswordPolicyConfig struct {
+	MinLength        int  `mapstructure:"min_length"`
+	MaxLength        int  `mapstructure:"max_length"`
+	MinLowercase     int  `mapstructure:"min_lowercase"`
+	MinUppercase     int  `mapstructure:"min_uppercase"`
+	MinDigits        int  `mapstructure:"min_digits"`
+	MinSymbols       int  `mapstructure:"min_symbols"`
+	DisallowUserInfo bool `mapstructure:"disallow_user_info"`
+	MinStrengthScore int  `mapstructure:"min_strength_score"`
+}
+
+// RateLimitPolicyConfig allows Limit requests per Period minutes for every value of Key, the bucket refills
+// continuously so short bursts up to Limit are accepted
+type RateLimitPolicyConfig struct {
+	Key    enum.RateLimitKeyValue `mapstructure:"key"`
+	Limit  int64                  `mapstructure:"limit"`
+	Period time.Duration          `mapstructure:"period"`
+}
+
+// OAuthClaimMapping names the id token claims read for each user field, empty entries use the standard claim
+type OAuthClaimMapping struct {
+	Subject       string `mapstructure:"subject"`
+	Email         string `mapstructure:"email"`
+	EmailVerified string `mapstructure:"email_verified"`
+	Username      string `mapstructure:"username"`
+	Name          string `mapstructure:"name"`
+}
+
 var (
 	AppConfig *appConfig
 )
@@ -54,6 +200,53 @@ func LoadConfig(configPath string) error {
 
 	v.AutomaticEnv()
 
+	// default values
+	v.SetDefault("two_factor_recovery_code_count", 10)
+	v.SetDefault("reset_password_token_expired_in", 15)
+	v.SetDefault("email_verification_expired_in", 1440)
+	v.SetDefault("email_verification_resend_in", 1)
+	v.SetDefault("magic_link_expired_in", 15)
+	v.SetDefault("webauthn_challenge_expired_in", 5)
+	v.SetDefault("magic_link_resend_in", 1)
+	v.SetDefault("password_policy.min_length", 8)
+	v.SetDefault("password_policy.max_length", 72)
+	v.SetDefault("password_policy.min_lowercase", 1)
+	v.SetDefault("password_policy.min_uppercase", 1)
+	v.SetDefault("password_policy.min_digits", 1)
+	v.SetDefault("password_policy.min_symbols", 1)
+	v.SetDefault("password_policy.disallow_user_info", true)
+	v.SetDefault("password_policy.min_strength_score", 2)
+	v.SetDefault("password_history_count", 5)
+	v.SetDefault("password_change_token_expired_in", 10)
+	v.SetDefault("breached_password_action", string(enum.BreachedPasswordAction.Reject))
+	v.SetDefault("breached_password_min_count", 1)
+	v.SetDefault("login_lockout_threshold", 5)
+	v.SetDefault("login_ip_lockout_threshold", 50)
+	v.SetDefault("login_lockout_duration", 15)
+	v.SetDefault("login_failure_window", 15)
+	v.SetDefault("login_backoff_base", 1)
+	v.SetDefault("login_backoff_max", 60)
+	v.SetDefault("rate_limit_enabled", true)
+	v.SetDefault("rate_limit_driver", string(enum.StorageDriver.Memory))
+	setRateLimitPolicyDefault(v, "signup", enum.RateLimitKey.IPAddress, 10, 60)
+	setRateLimitPolicyDefault(v, "login_ip", enum.RateLimitKey.IPAddress, 20, 1)
+	setRateLimitPolicyDefault(v, "login_email", enum.RateLimitKey.Email, 10, 15)
+	setRateLimitPolicyDefault(v, "login_2fa", enum.RateLimitKey.IPAddress, 10, 1)
+	setRateLimitPolicyDefault(v, "refresh", enum.RateLimitKey.IPAddress, 60, 1)
+	setRateLimitPolicyDefault(v, "forget_password_ip", enum.RateLimitKey.IPAddress, 10, 15)
+	setRateLimitPolicyDefault(v, "forget_password_email", enum.RateLimitKey.Email, 3, 15)
+	setRateLimitPolicyDefault(v, "reset_password", enum.RateLimitKey.UserID, 5, 15)
+	v.SetDefault("notification_driver", string(enum.NotificationDriver.Log))
+	v.SetDefault("revoked_token_driver", string(enum.StorageDriver.Mongo))
+	v.SetDefault("access_token_signing_method", "HS256")
+	v.SetDefault("access_token_audience", "realworld-authentication")
+	v.SetDefault("signing_key_refresh_in", 1)
+	v.SetDefault("oidc_authorization_code_expired_in", 5)
+	v.SetDefault("github_oauth_base_url", "https://github.com")
+	v.SetDefault("github_api_base_url", "https://api.github.com")
+	v.SetDefault("oauth_state_expired_in", 10)
+	v.SetDefault("oauth_return_paths", []string{"/"})
+
 	if err := v.ReadInConfig(); err != nil {
 		return fmt.Errorf("failed to read config file: %v", err)
 	}
@@ -64,3 +257,10 @@ func LoadConfig(configPath string) error {
 
 	return nil
 }
+
+// setRateLimitPolicyDefault sets every field on its own, a policy partly set in config keeps the other defaults
+func setRateLimitPolicyDefault(v *viper.Viper, name string, key enum.RateLimitKeyValue, limit, period int64) {
+	v.SetDefault("rate_limit_policies."+name+".key", string(key))
+	v.SetDefault("rate_limit_policies."+name+".limit", limit)
+	v.SetDefault("rate_limit_policies."+name+".period", period)
+}
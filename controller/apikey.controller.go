@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"net/http"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeyController struct {
+	APIKeyService APIKeyService
+	Validator     *validator.Validate
+}
+
+func NewAPIKeyController(apiKeyService APIKeyService, validator *validator.Validate) *APIKeyController {
+	return &APIKeyController{
+		APIKeyService: apiKeyService,
+		Validator:     validator,
+	}
+}
+
+func (h *APIKeyController) CreateAPIKey(c echo.Context) error {
+	var (
+		input  user.CreateAPIKeyDto
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	apiKeyResp, err := h.APIKeyService.CreateAPIKey(userID, &input)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Create API key successfully",
+		Data:    apiKeyResp,
+	})
+}
+
+func (h *APIKeyController) GetMyAPIKeys(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	apiKeysResp, err := h.APIKeyService.GetMyAPIKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Get API keys successfully",
+		Data:    apiKeysResp,
+	})
+}
+
+func (h *APIKeyController) RevokeAPIKey(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+		keyID  = c.Param("keyID")
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := h.APIKeyService.RevokeAPIKey(userID, keyID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &helper.APIResponse{
+			Status:  helper.APIStatus.Notfound,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Revoke API key successfully",
+	})
+}
@@ -1,19 +1,30 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"realworld-authentication/config/env"
 	"realworld-authentication/dto/auth"
 	"realworld-authentication/dto/user"
+	"realworld-authentication/entity"
 	"realworld-authentication/helper"
 	"realworld-authentication/model/enum"
 	"realworld-authentication/utils"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	HeaderDeviceName = "X-Device-Name"
+	OAuthStateCookie = "oauth_state"
+)
+
 type AuthController struct {
 	AuthService AuthService
 	FileService FileService
@@ -65,15 +76,72 @@ func (h *AuthController) SignUp(c echo.Context) error {
 		})
 	}
 
-	if !utils.ValidatePassword(input.User.Password) {
+	userSignupResponse, err := h.AuthService.SignUp(&input)
+	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Sign up successfully",
+		Data:    userSignupResponse,
+	})
+}
+
+func (h *AuthController) VerifyEmail(c echo.Context) error {
+	var token = c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing verification token",
+		})
+	}
+
+	verifyEmailResp, err := h.AuthService.VerifyEmail(token)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Verify email successfully",
+		Data:    verifyEmailResp,
+	})
+}
+
+func (h *AuthController) ResendVerificationEmail(c echo.Context) error {
+	var input auth.ResendVerificationEmailDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
 			Status:    helper.APIStatus.Invalid,
-			Message:   "User password is invalid format",
-			ErrorCode: string(enum.ErrorCodeInvalid.Password),
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
 		})
 	}
 
-	userSignupResponse, err := h.AuthService.SignUp(&input)
+	err = h.AuthService.ResendVerificationEmail(input.User.Email)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
 			Status:  helper.APIStatus.Invalid,
@@ -81,10 +149,9 @@ func (h *AuthController) SignUp(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusCreated, &helper.APIResponse{
+	return c.JSON(http.StatusOK, &helper.APIResponse{
 		Status:  helper.APIStatus.Ok,
-		Message: "Sign up successfully",
-		Data:    userSignupResponse,
+		Message: "If the email is pending verification, a new verification link has been sent",
 	})
 }
 
@@ -109,8 +176,14 @@ func (h *AuthController) Login(c echo.Context) error {
 		})
 	}
 
+	input.Client = getSessionClient(c)
 	userLoginResp, err := h.AuthService.Login(&input)
 	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+
 		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
 			Status:  helper.APIStatus.Invalid,
 			Message: err.Error(),
@@ -119,7 +192,115 @@ func (h *AuthController) Login(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, &helper.APIResponse{
 		Status:  helper.APIStatus.Ok,
-		Message: "Login successfully",
+		Message: getLoginMessage(userLoginResp),
+		Data:    userLoginResp,
+	})
+}
+
+func (h *AuthController) LoginWithTwoFactor(c echo.Context) error {
+	var input auth.TwoFactorLoginDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	input.Client = getSessionClient(c)
+	userLoginResp, err := h.AuthService.LoginWithTwoFactor(&input)
+	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: getLoginMessage(userLoginResp),
+		Data:    userLoginResp,
+	})
+}
+
+func (h *AuthController) SendMagicLink(c echo.Context) error {
+	var input auth.MagicLinkRequestDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	err = h.AuthService.SendMagicLink(input.User.Email)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "If the email is registered, a login link has been sent",
+	})
+}
+
+func (h *AuthController) VerifyMagicLink(c echo.Context) error {
+	var token = c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing login token",
+		})
+	}
+
+	userLoginResp, err := h.AuthService.LoginWithMagicLink(&auth.MagicLinkLoginDto{
+		Token:  token,
+		Client: getSessionClient(c),
+	})
+	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: getLoginMessage(userLoginResp),
 		Data:    userLoginResp,
 	})
 }
@@ -135,6 +316,7 @@ func (h *AuthController) RefreshToken(c echo.Context) error {
 		})
 	}
 
+	request.Client = getSessionClient(c)
 	refreshTokenResp, err := h.AuthService.RefreshToken(&request)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
@@ -150,6 +332,22 @@ func (h *AuthController) RefreshToken(c echo.Context) error {
 	})
 }
 
+func (h *AuthController) ProviderOauthStart(c echo.Context) error {
+	startResp, err := h.AuthService.StartProviderLogin(&auth.ProviderLoginStartDto{
+		Provider:   c.Param("provider"),
+		ReturnPath: c.QueryParam("returnPath"),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	setOAuthStateCookie(c, startResp.StateToken, int(env.AppConfig.OAuthStateExpiredIn)*60)
+	return c.Redirect(http.StatusFound, startResp.AuthorizationUrl)
+}
+
 func (h *AuthController) GoogleOauth(c echo.Context) error {
 	code := c.QueryParam("code")
 	if code == "" {
@@ -159,34 +357,98 @@ func (h *AuthController) GoogleOauth(c echo.Context) error {
 		})
 	}
 
-	pathUrl := "/"
-	if c.QueryParam("state") != "" {
-		pathUrl = c.QueryParam("state")
+	googleSignInResp, err := h.AuthService.LoginWithGoogle(&auth.GoogleLoginDto{
+		AuthorizationCode: code,
+		State:             c.QueryParam("state"),
+		StateToken:        getOAuthStateCookie(c),
+		Client:            getSessionClient(c),
+	})
+
+	return completeOauth(c, googleSignInResp, err)
+}
+
+func (h *AuthController) GithubOauth(c echo.Context) error {
+	code := c.QueryParam("code")
+	if code == "" {
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: "Authorization code not be provided",
+		})
+	}
+
+	githubSignInResp, err := h.AuthService.LoginWithGithub(&auth.GithubLoginDto{
+		AuthorizationCode: code,
+		State:             c.QueryParam("state"),
+		StateToken:        getOAuthStateCookie(c),
+		Client:            getSessionClient(c),
+	})
+
+	return completeOauth(c, githubSignInResp, err)
+}
+
+func (h *AuthController) ProviderOauth(c echo.Context) error {
+	code := c.QueryParam("code")
+	if code == "" {
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: "Authorization code not be provided",
+		})
 	}
 
-	googleSignInResp, err := h.AuthService.LoginWithGoogle(&auth.GoogleLoginDto{
+	providerSignInResp, err := h.AuthService.LoginWithProvider(&auth.ProviderLoginDto{
+		Provider:          c.Param("provider"),
 		AuthorizationCode: code,
-		PathUrl:           pathUrl,
+		State:             c.QueryParam("state"),
+		StateToken:        getOAuthStateCookie(c),
+		Client:            getSessionClient(c),
 	})
 
+	return completeOauth(c, providerSignInResp, err)
+}
+
+// completeOauth drops the state cookie, it is single use whether the login succeeded or not. A login that still
+// needs a second factor or a password change gets no access token cookie, the pending token is handed to the
+// client in the url fragment so it never reaches a server log. A linked provider only returns to the client
+func completeOauth(c echo.Context, signInResp *entity.SocialLoginResponse, err error) error {
+	setOAuthStateCookie(c, "", -1)
+
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
-			Status:  helper.APIStatus.Error,
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
 			Message: err.Error(),
 		})
 	}
 
-	c.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    googleSignInResp.Token.AccessToken,
-		Path:     "/",
-		Domain:   "localhost",
-		Secure:   false,
-		HttpOnly: true,
-		MaxAge:   int(env.AppConfig.AccessTokenMaxAge) * 60,
-	})
+	redirectUrl := fmt.Sprintf(env.AppConfig.ClientOrigin, signInResp.ReturnPath)
+	switch {
+	case signInResp.ChallengeToken != "":
+		methods := make([]string, 0, len(signInResp.TwoFactorMethods))
+		for _, method := range signInResp.TwoFactorMethods {
+			methods = append(methods, string(method))
+		}
+		redirectUrl += "#" + url.Values{
+			"twoFactorRequired": {"true"},
+			"twoFactorMethods":  {strings.Join(methods, ",")},
+			"challengeToken":    {signInResp.ChallengeToken},
+		}.Encode()
+	case signInResp.LinkedProvider != "":
+		redirectUrl += "#" + url.Values{
+			"identityLinked": {signInResp.LinkedProvider},
+		}.Encode()
+	case signInResp.PasswordChangeToken != "":
+		redirectUrl += "#" + url.Values{
+			"passwordChangeRequired": {"true"},
+			"passwordChangeToken":    {signInResp.PasswordChangeToken},
+		}.Encode()
+	default:
+		setTokenCookie(c, signInResp.Token.AccessToken)
+	}
 
-	return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf(env.AppConfig.ClientOrigin, pathUrl))
+	return c.Redirect(http.StatusTemporaryRedirect, redirectUrl)
 }
 
 func (h *AuthController) GetUserProfileByID(c echo.Context) error {
@@ -215,6 +477,14 @@ func (h *AuthController) UpdateUserProfile(c echo.Context) error {
 		input  user.UserProfileUpdateDto
 	)
 
+	// the profile holds the email used for password resets, only its owner may change it
+	if userID == "" || userID != getUserIDFromToken(c) {
+		return c.JSON(http.StatusForbidden, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Your account cannot perform this action",
+		})
+	}
+
 	err := c.Bind(&input)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
@@ -306,15 +576,13 @@ func (h *AuthController) ResetUserPassword(c echo.Context) error {
 		})
 	}
 
-	if !utils.ValidatePassword(input.User.NewPassword) {
-		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
-			Status:  helper.APIStatus.Invalid,
-			Message: "Password is invalid format",
-		})
-	}
-
 	userResetPassword, err := h.AuthService.ResetPassword(userID, &input)
 	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+
 		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
 			Status:  helper.APIStatus.Error,
 			Message: err.Error(),
@@ -328,41 +596,22 @@ func (h *AuthController) ResetUserPassword(c echo.Context) error {
 	})
 }
 
-func (h *AuthController) UploadFile(c echo.Context) error {
-	file, err := c.FormFile("file")
+func (h *AuthController) RequirePasswordChange(c echo.Context) error {
+	err := h.AuthService.RequirePasswordChange(getUserIDFromToken(c), c.Param("userID"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
 			Status:  helper.APIStatus.Invalid,
 			Message: err.Error(),
 		})
 	}
-	fileType := file.Header.Get("Content-Type")
-
-	src, err := file.Open()
-	if err != nil {
-		return c.JSON(http.StatusBadGateway, &helper.APIResponse{
-			Status:  helper.APIStatus.Error,
-			Message: err.Error(),
-		})
-	}
-	defer src.Close()
-
-	uploadFileResp, err := h.FileService.UploadFile(file.Filename, src, fileType)
-	if err != nil {
-		return c.JSON(http.StatusBadGateway, &helper.APIResponse{
-			Status:  helper.APIStatus.Error,
-			Message: err.Error(),
-		})
-	}
 
 	return c.JSON(http.StatusOK, &helper.APIResponse{
 		Status:  helper.APIStatus.Ok,
-		Message: "Upload file successfully",
-		Data:    uploadFileResp,
+		Message: "Password change is required at next login",
 	})
 }
 
-func (h *AuthController) Logout(c echo.Context) error {
+func (h *AuthController) SetupTwoFactor(c echo.Context) error {
 	var (
 		userID = getUserIDFromToken(c)
 	)
@@ -374,7 +623,7 @@ func (h *AuthController) Logout(c echo.Context) error {
 		})
 	}
 
-	err := h.AuthService.Logout(userID)
+	twoFactorSetupResp, err := h.AuthService.SetupTwoFactor(userID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
 			Status:  helper.APIStatus.Error,
@@ -384,23 +633,386 @@ func (h *AuthController) Logout(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, &helper.APIResponse{
 		Status:  helper.APIStatus.Ok,
-		Message: "Logout successfully",
+		Message: "Setup two factor authentication successfully",
+		Data:    twoFactorSetupResp,
 	})
 }
 
-func (h *AuthController) ForgetPassword(c echo.Context) error {
-	var userEmail = c.QueryParam("email")
-	if userEmail == "" {
+func (h *AuthController) ConfirmTwoFactor(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+		input  user.TwoFactorConfirmDto
+	)
+
+	if userID == "" {
 		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
 			Status:  helper.APIStatus.Invalid,
-			Message: "Missing email",
+			Message: "Missing User ID",
 		})
 	}
 
-	forgetPasswordResp, err := h.AuthService.ForgetPassword(userEmail)
+	err := c.Bind(&input)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
-			Status:  helper.APIStatus.Error,
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	twoFactorStatusResp, err := h.AuthService.ConfirmTwoFactor(userID, &input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Enable two factor authentication successfully",
+		Data:    twoFactorStatusResp,
+	})
+}
+
+func (h *AuthController) RegenerateRecoveryCodes(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	recoveryCodesResp, err := h.AuthService.RegenerateRecoveryCodes(userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Regenerate recovery codes successfully",
+		Data:    recoveryCodesResp,
+	})
+}
+
+func (h *AuthController) UploadFile(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+	fileType := file.Header.Get("Content-Type")
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+	defer src.Close()
+
+	uploadFileResp, err := h.FileService.UploadFile(file.Filename, src, fileType)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Upload file successfully",
+		Data:    uploadFileResp,
+	})
+}
+
+func (h *AuthController) Logout(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := h.AuthService.Logout(&auth.LogoutDto{
+		UserID:    userID,
+		SessionID: getSessionIDFromToken(c),
+		TokenID:   getTokenIDFromToken(c),
+		ExpiredIn: getTokenExpiredInFromToken(c),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Logout successfully",
+	})
+}
+
+func (h *AuthController) GetMySessions(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	sessionsResp, err := h.AuthService.GetMySessions(userID, getSessionIDFromToken(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Get sessions successfully",
+		Data:    sessionsResp,
+	})
+}
+
+func (h *AuthController) RevokeSession(c echo.Context) error {
+	var (
+		userID    = getUserIDFromToken(c)
+		sessionID = c.Param("sessionID")
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := h.AuthService.RevokeSession(userID, sessionID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &helper.APIResponse{
+			Status:  helper.APIStatus.Notfound,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Revoke session successfully",
+	})
+}
+
+func (h *AuthController) RevokeOtherSessions(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := h.AuthService.RevokeOtherSessions(userID, getSessionIDFromToken(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Logout other sessions successfully",
+	})
+}
+
+func (h *AuthController) GetMyIdentities(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	identitiesResp, err := h.AuthService.GetMyIdentities(userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &helper.APIResponse{
+			Status:  helper.APIStatus.Notfound,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Get linked providers successfully",
+		Data:    identitiesResp,
+	})
+}
+
+func (h *AuthController) LinkIdentity(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+		input  user.LinkIdentityDto
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	// the browser opens authorizationUrl, the provider callback links the account once the state cookie matches
+	input.Provider = c.Param("provider")
+	startResp, err := h.AuthService.StartLinkIdentity(userID, &input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	setOAuthStateCookie(c, startResp.StateToken, int(env.AppConfig.OAuthStateExpiredIn)*60)
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Continue at the provider to link the account",
+		Data:    startResp,
+	})
+}
+
+func (h *AuthController) UnlinkIdentity(c echo.Context) error {
+	var (
+		userID   = getUserIDFromToken(c)
+		provider = c.Param("provider")
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	identitiesResp, err := h.AuthService.UnlinkIdentity(userID, provider)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Unlink provider successfully",
+		Data:    identitiesResp,
+	})
+}
+
+func (h *AuthController) ForgetPassword(c echo.Context) error {
+	var userEmail = c.QueryParam("email")
+	if userEmail == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing email",
+		})
+	}
+
+	err := h.AuthService.ForgetPassword(userEmail)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "If the email is registered, a reset password link has been sent",
+	})
+}
+
+func (h *AuthController) ResetPasswordWithToken(c echo.Context) error {
+	var input auth.ResetPasswordDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	resetPasswordResp, err := h.AuthService.ResetPasswordWithToken(&input)
+	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
 			Message: err.Error(),
 		})
 	}
@@ -408,10 +1020,43 @@ func (h *AuthController) ForgetPassword(c echo.Context) error {
 	return c.JSON(http.StatusOK, &helper.APIResponse{
 		Status:  helper.APIStatus.Ok,
 		Message: "Reset password successfully",
-		Data:    forgetPasswordResp,
+		Data:    resetPasswordResp,
+	})
+}
+
+func setTokenCookie(c echo.Context, accessToken string) {
+	c.SetCookie(&http.Cookie{
+		Name:     "token",
+		Value:    accessToken,
+		Path:     "/",
+		Domain:   "localhost",
+		Secure:   false,
+		HttpOnly: true,
+		MaxAge:   int(env.AppConfig.AccessTokenMaxAge) * 60,
+	})
+}
+
+// setOAuthStateCookie is sent back on the top level redirect from the provider, so it needs SameSite lax
+func setOAuthStateCookie(c echo.Context, stateToken string, maxAge int) {
+	c.SetCookie(&http.Cookie{
+		Name:     OAuthStateCookie,
+		Value:    stateToken,
+		Path:     "/api/sessions/oauth",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
 	})
 }
 
+func getOAuthStateCookie(c echo.Context) string {
+	cookie, err := c.Cookie(OAuthStateCookie)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
 func getUserIDFromToken(c echo.Context) string {
 	userID, ok := c.Get("userId").(string)
 	if !ok {
@@ -420,3 +1065,81 @@ func getUserIDFromToken(c echo.Context) string {
 
 	return userID
 }
+
+func getSessionIDFromToken(c echo.Context) string {
+	sessionID, ok := c.Get("sessionId").(string)
+	if !ok {
+		return ""
+	}
+
+	return sessionID
+}
+
+func getTokenIDFromToken(c echo.Context) string {
+	tokenID, ok := c.Get("tokenId").(string)
+	if !ok {
+		return ""
+	}
+
+	return tokenID
+}
+
+func getTokenExpiredInFromToken(c echo.Context) *int64 {
+	expiredIn, ok := c.Get("tokenExpiredIn").(int64)
+	if !ok {
+		return nil
+	}
+
+	return &expiredIn
+}
+
+func getScopeFromToken(c echo.Context) string {
+	scope, ok := c.Get("scope").(string)
+	if !ok {
+		return ""
+	}
+
+	return scope
+}
+
+// getLoginMessage tells the client what the login response holds, tokens or the step still needed
+func getLoginMessage(userLoginResp *entity.UserLoginResponse) string {
+	switch {
+	case userLoginResp.User.TwoFactorRequired != nil && *userLoginResp.User.TwoFactorRequired:
+		return "Two factor authentication is required"
+	case userLoginResp.User.PasswordChangeRequired != nil && *userLoginResp.User.PasswordChangeRequired:
+		return "Password change is required"
+	default:
+		return "Login successfully"
+	}
+}
+
+// writeAPIError responds with the status matched with the error code, the caller is told when to retry if it can
+func writeAPIError(c echo.Context, apiErr *helper.APIError) error {
+	status := http.StatusBadRequest
+	switch apiErr.Code {
+	case enum.ErrorCodeLocked.Account, enum.ErrorCodeLocked.IPAddress:
+		status = http.StatusLocked
+	case enum.ErrorCodeTooManyRequests.Login:
+		status = http.StatusTooManyRequests
+	}
+
+	if apiErr.RetryAfter > 0 {
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(int64(math.Ceil(apiErr.RetryAfter.Seconds())), 10))
+	}
+
+	return c.JSON(status, &helper.APIResponse{
+		Status:    helper.APIStatus.Invalid,
+		Message:   apiErr.Message,
+		ErrorCode: string(apiErr.Code),
+		Data:      apiErr.Data,
+	})
+}
+
+func getSessionClient(c echo.Context) auth.SessionClientDto {
+	return auth.SessionClientDto{
+		DeviceName: c.Request().Header.Get(HeaderDeviceName),
+		UserAgent:  c.Request().UserAgent(),
+		IPAddress:  c.RealIP(),
+	}
+}
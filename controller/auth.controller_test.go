@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/entity"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type fakeAuthService struct {
+	AuthService
+	updatedUserIDs []string
+}
+
+func (f *fakeAuthService) UpdateUserProfile(userID string, input *user.UserProfileUpdateDto) (*entity.UserProfileResponse, error) {
+	f.updatedUserIDs = append(f.updatedUserIDs, userID)
+	return &entity.UserProfileResponse{}, nil
+}
+
+func newProfileUpdateContext(tokenUserID, paramUserID string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPut, "/api/users/"+paramUserID+"/profile", strings.NewReader(`{"user":{"email":"attacker@example.com"}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+	c.SetPath("/api/users/:userID/profile")
+	c.SetParamNames("userID")
+	c.SetParamValues(paramUserID)
+	c.Set("userId", tokenUserID)
+	return c, rec
+}
+
+func TestUpdateUserProfileRejectsOtherUser(t *testing.T) {
+	authService := &fakeAuthService{}
+	h := NewAuthController(authService, nil, validator.New())
+
+	c, rec := newProfileUpdateContext("attacker", "victim")
+	if err := h.UpdateUserProfile(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("UpdateUserProfile() for another user: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if len(authService.updatedUserIDs) != 0 {
+		t.Fatalf("UpdateUserProfile() updated %v", authService.updatedUserIDs)
+	}
+
+	c, rec = newProfileUpdateContext("victim", "victim")
+	if err := h.UpdateUserProfile(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || len(authService.updatedUserIDs) != 1 || authService.updatedUserIDs[0] != "victim" {
+		t.Fatalf("UpdateUserProfile() own profile: status = %d, updated %v", rec.Code, authService.updatedUserIDs)
+	}
+}
@@ -2,25 +2,88 @@ package controller
 
 import (
 	"mime/multipart"
+	"realworld-authentication/dto/admin"
 	"realworld-authentication/dto/auth"
+	"realworld-authentication/dto/oauth"
 	"realworld-authentication/dto/user"
 	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"time"
 )
 
 type AuthService interface {
 	SignUp(input *auth.UserSignUpDto) (*entity.UserSignUpResponse, error)
+	VerifyEmail(token string) (*entity.UserProfileResponse, error)
+	ResendVerificationEmail(email string) error
 	Login(input *auth.UserLoginDto) (*entity.UserLoginResponse, error)
+	LoginWithTwoFactor(input *auth.TwoFactorLoginDto) (*entity.UserLoginResponse, error)
+	SendMagicLink(email string) error
+	BeginWebAuthnLogin(input *auth.WebAuthnLoginBeginDto) (*entity.WebAuthnLoginOptionsResponse, error)
+	LoginWithWebAuthn(input *auth.WebAuthnLoginDto) (*entity.UserLoginResponse, error)
+	BeginWebAuthnTwoFactor(input *auth.WebAuthnTwoFactorBeginDto) (*entity.WebAuthnLoginOptionsResponse, error)
+	LoginWithWebAuthnTwoFactor(input *auth.WebAuthnTwoFactorLoginDto) (*entity.UserLoginResponse, error)
+	LoginWithMagicLink(input *auth.MagicLinkLoginDto) (*entity.UserLoginResponse, error)
 	RefreshToken(input *auth.RefreshTokenRequestDto) (*entity.TokenResponse, error)
-	Logout(userID string) error
-	LoginWithGoogle(input *auth.GoogleLoginDto) (*entity.GoogleOauthTokenResponse, error)
+	Logout(input *auth.LogoutDto) error
+	LoginWithGoogle(input *auth.GoogleLoginDto) (*entity.SocialLoginResponse, error)
+	LoginWithGithub(input *auth.GithubLoginDto) (*entity.SocialLoginResponse, error)
+	StartProviderLogin(input *auth.ProviderLoginStartDto) (*entity.ProviderLoginStartResponse, error)
+	LoginWithProvider(input *auth.ProviderLoginDto) (*entity.SocialLoginResponse, error)
 
 	GetUserProfileByID(userID string) (*entity.UserProfileResponse, error)
 	UpdateUserProfile(userID string, input *user.UserProfileUpdateDto) (*entity.UserProfileResponse, error)
 	ResetPassword(userID string, input *user.UserResetPasswordDto) (*entity.UserPasswordResponse, error)
-	ForgetPassword(email string) (*entity.UserPasswordResponse, error)
+	RequirePasswordChange(adminID, userID string) error
+	ForgetPassword(email string) error
+	ResetPasswordWithToken(input *auth.ResetPasswordDto) (*entity.UserPasswordResponse, error)
+	SetupTwoFactor(userID string) (*entity.TwoFactorSetupResponse, error)
+	ConfirmTwoFactor(userID string, input *user.TwoFactorConfirmDto) (*entity.TwoFactorStatusResponse, error)
+	RegenerateRecoveryCodes(userID string) (*entity.TwoFactorStatusResponse, error)
+	BeginWebAuthnRegistration(userID string) (*entity.WebAuthnRegistrationOptionsResponse, error)
+	FinishWebAuthnRegistration(userID string, input *user.WebAuthnRegisterDto) (*entity.WebAuthnCredentialResponse, error)
+	GetMyWebAuthnCredentials(userID string) (*entity.WebAuthnCredentialListResponse, error)
+	DeleteWebAuthnCredential(userID, credentialID string) error
+	GetMySessions(userID, currentSessionID string) (*entity.SessionListResponse, error)
+	RevokeSession(userID, sessionID string) error
+	RevokeOtherSessions(userID, currentSessionID string) error
+	GetMyIdentities(userID string) (*entity.IdentityListResponse, error)
+	StartLinkIdentity(userID string, input *user.LinkIdentityDto) (*entity.ProviderLoginStartResponse, error)
+	UnlinkIdentity(userID, provider string) (*entity.IdentityListResponse, error)
 }
 
 type FileService interface {
 	UploadFile(fileName string, src multipart.File, fileType string) (*entity.UploadFileResponse, error)
 	DeleteFile(fileName string) error
 }
+
+type APIKeyService interface {
+	CreateAPIKey(userID string, input *user.CreateAPIKeyDto) (*entity.APIKeyResponse, error)
+	GetMyAPIKeys(userID string) (*entity.APIKeyListResponse, error)
+	RevokeAPIKey(userID, keyID string) error
+}
+
+type KeyService interface {
+	GetJWKS() *helper.JWKS
+	GetSigningKeys() (*entity.SigningKeyListResponse, error)
+	CreateSigningKey(input *admin.CreateSigningKeyDto) (*entity.SigningKeyResponse, error)
+	PromoteSigningKey(keyID string) (*entity.SigningKeyResponse, error)
+}
+
+type OAuthService interface {
+	GetOpenIDConfiguration() *entity.OpenIDConfigurationResponse
+	ValidateAuthorizeClient(input *oauth.AuthorizeDto) (*model.Client, error)
+	Authorize(input *oauth.AuthorizeDto) (*entity.AuthorizeResponse, error)
+	ExchangeToken(input *oauth.TokenDto) (*entity.OAuthTokenResponse, error)
+	GetUserInfo(userID, scope string) (*entity.UserInfoResponse, error)
+	GetClients() (*entity.ClientListResponse, error)
+	CreateClient(input *admin.CreateClientDto) (*entity.ClientResponse, error)
+	DeleteClient(clientID string) error
+}
+
+type NotificationService interface {
+	SendResetPasswordEmail(email, resetLink string) error
+	SendVerificationEmail(email, verifyLink string) error
+	SendMagicLinkEmail(email, loginLink string) error
+	SendAccountLockedEmail(email string, lockedFor time.Duration) error
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"net/http"
+	"realworld-authentication/dto/admin"
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type KeyController struct {
+	KeyService KeyService
+	Validator  *validator.Validate
+}
+
+func NewKeyController(keyService KeyService, validator *validator.Validate) *KeyController {
+	return &KeyController{
+		KeyService: keyService,
+		Validator:  validator,
+	}
+}
+
+// GetJWKS serves the plain JWK Set document (RFC 7517) expected by downstream verifiers, not an APIResponse
+func (h *KeyController) GetJWKS(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(http.StatusOK, h.KeyService.GetJWKS())
+}
+
+func (h *KeyController) GetSigningKeys(c echo.Context) error {
+	keysResp, err := h.KeyService.GetSigningKeys()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Get signing keys successfully",
+		Data:    keysResp,
+	})
+}
+
+func (h *KeyController) CreateSigningKey(c echo.Context) error {
+	var input admin.CreateSigningKeyDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	keyResp, err := h.KeyService.CreateSigningKey(&input)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Create signing key successfully",
+		Data:    keyResp,
+	})
+}
+
+func (h *KeyController) PromoteSigningKey(c echo.Context) error {
+	keyResp, err := h.KeyService.PromoteSigningKey(c.Param("keyID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Promote signing key successfully",
+		Data:    keyResp,
+	})
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/admin"
+	"realworld-authentication/dto/oauth"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type OAuthController struct {
+	OAuthService OAuthService
+	Validator    *validator.Validate
+}
+
+func NewOAuthController(oauthService OAuthService, validator *validator.Validate) *OAuthController {
+	return &OAuthController{
+		OAuthService: oauthService,
+		Validator:    validator,
+	}
+}
+
+func (h *OAuthController) GetOpenIDConfiguration(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(http.StatusOK, h.OAuthService.GetOpenIDConfiguration())
+}
+
+// AuthorizeRedirect is the authorization endpoint opened by relying parties, the user is sent to the login page
+// with the original request which it posts back to Authorize once logged in
+func (h *OAuthController) AuthorizeRedirect(c echo.Context) error {
+	var input oauth.AuthorizeDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	_, err = h.OAuthService.ValidateAuthorizeClient(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	location, err := helper.AppendQuery(env.AppConfig.OIDCLoginUrl, c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Redirect(http.StatusFound, location)
+}
+
+func (h *OAuthController) Authorize(c echo.Context) error {
+	var input oauth.AuthorizeDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	input.UserID = getUserIDFromToken(c)
+	if input.UserID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	authorizeResp, err := h.OAuthService.Authorize(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Authorize client successfully",
+		Data:    authorizeResp,
+	})
+}
+
+// Token answers with the RFC 6749 token and error formats instead of APIResponse, OAuth client libraries parse them
+func (h *OAuthController) Token(c echo.Context) error {
+	var input oauth.TokenDto
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+
+	err := c.Bind(&input)
+	if err != nil {
+		return writeOAuthError(c, helper.NewOAuthError(enum.OAuthErrorCode.InvalidRequest, "Parse data error. "+err.Error()))
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return writeOAuthError(c, helper.NewOAuthError(enum.OAuthErrorCode.InvalidRequest, "Validate error: "+err.Error()))
+	}
+
+	// client_secret_basic takes precedence over client_secret_post
+	if clientID, clientSecret, ok := c.Request().BasicAuth(); ok {
+		input.ClientID = clientID
+		input.ClientSecret = clientSecret
+	}
+
+	tokenResp, err := h.OAuthService.ExchangeToken(&input)
+	if err != nil {
+		return writeOAuthError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, tokenResp)
+}
+
+func (h *OAuthController) GetUserInfo(c echo.Context) error {
+	userInfoResp, err := h.OAuthService.GetUserInfo(getUserIDFromToken(c), getScopeFromToken(c))
+	if err != nil {
+		var oauthErr *helper.OAuthError
+		if errors.As(err, &oauthErr) {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="`+string(oauthErr.Code)+`"`)
+			return c.JSON(http.StatusForbidden, &entity.OAuthErrorResponse{
+				Error:            string(oauthErr.Code),
+				ErrorDescription: oauthErr.Description,
+			})
+		}
+
+		return c.JSON(http.StatusNotFound, &helper.APIResponse{
+			Status:  helper.APIStatus.Notfound,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, userInfoResp)
+}
+
+func (h *OAuthController) GetClients(c echo.Context) error {
+	clientsResp, err := h.OAuthService.GetClients()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Get clients successfully",
+		Data:    clientsResp,
+	})
+}
+
+func (h *OAuthController) CreateClient(c echo.Context) error {
+	var input admin.CreateClientDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	clientResp, err := h.OAuthService.CreateClient(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Create client successfully",
+		Data:    clientResp,
+	})
+}
+
+func (h *OAuthController) DeleteClient(c echo.Context) error {
+	err := h.OAuthService.DeleteClient(c.Param("clientID"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &helper.APIResponse{
+			Status:  helper.APIStatus.Notfound,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Delete client successfully",
+	})
+}
+
+func writeOAuthError(c echo.Context, err error) error {
+	var oauthErr *helper.OAuthError
+	if !errors.As(err, &oauthErr) {
+		oauthErr = helper.NewOAuthError(enum.OAuthErrorCode.ServerError, err.Error())
+	}
+
+	status := http.StatusBadRequest
+	switch oauthErr.Code {
+	case enum.OAuthErrorCode.InvalidClient:
+		status = http.StatusUnauthorized
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	case enum.OAuthErrorCode.ServerError:
+		status = http.StatusInternalServerError
+	}
+
+	return c.JSON(status, &entity.OAuthErrorResponse{
+		Error:            string(oauthErr.Code),
+		ErrorDescription: oauthErr.Description,
+	})
+}
@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"realworld-authentication/dto/auth"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *AuthController) BeginWebAuthnRegistration(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	registrationOptionsResp, err := h.AuthService.BeginWebAuthnRegistration(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Begin security key registration successfully",
+		Data:    registrationOptionsResp,
+	})
+}
+
+func (h *AuthController) FinishWebAuthnRegistration(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+		input  user.WebAuthnRegisterDto
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	credentialResp, err := h.AuthService.FinishWebAuthnRegistration(userID, &input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Register security key successfully",
+		Data:    credentialResp,
+	})
+}
+
+func (h *AuthController) GetMyWebAuthnCredentials(c echo.Context) error {
+	var (
+		userID = getUserIDFromToken(c)
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	credentialsResp, err := h.AuthService.GetMyWebAuthnCredentials(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Get security keys successfully",
+		Data:    credentialsResp,
+	})
+}
+
+func (h *AuthController) DeleteWebAuthnCredential(c echo.Context) error {
+	var (
+		userID       = getUserIDFromToken(c)
+		credentialID = c.Param("credentialID")
+	)
+
+	if userID == "" {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "Missing User ID",
+		})
+	}
+
+	err := h.AuthService.DeleteWebAuthnCredential(userID, credentialID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Delete security key successfully",
+	})
+}
+
+func (h *AuthController) BeginWebAuthnLogin(c echo.Context) error {
+	var input auth.WebAuthnLoginBeginDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	loginOptionsResp, err := h.AuthService.BeginWebAuthnLogin(&input)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &helper.APIResponse{
+			Status:  helper.APIStatus.Error,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Begin security key login successfully",
+		Data:    loginOptionsResp,
+	})
+}
+
+func (h *AuthController) LoginWithWebAuthn(c echo.Context) error {
+	var input auth.WebAuthnLoginDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	input.Client = getSessionClient(c)
+	userLoginResp, err := h.AuthService.LoginWithWebAuthn(&input)
+	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: getLoginMessage(userLoginResp),
+		Data:    userLoginResp,
+	})
+}
+
+func (h *AuthController) BeginWebAuthnTwoFactor(c echo.Context) error {
+	var input auth.WebAuthnTwoFactorBeginDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	loginOptionsResp, err := h.AuthService.BeginWebAuthnTwoFactor(&input)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: "Begin security key verification successfully",
+		Data:    loginOptionsResp,
+	})
+}
+
+func (h *AuthController) LoginWithWebAuthnTwoFactor(c echo.Context) error {
+	var input auth.WebAuthnTwoFactorLoginDto
+
+	err := c.Bind(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Parse data error. " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.ParseData),
+		})
+	}
+
+	err = h.Validator.Struct(&input)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &helper.APIResponse{
+			Status:    helper.APIStatus.Invalid,
+			Message:   "Validate error: " + err.Error(),
+			ErrorCode: string(enum.ErrorCodeInvalid.InvalidFields),
+		})
+	}
+
+	input.Client = getSessionClient(c)
+	userLoginResp, err := h.AuthService.LoginWithWebAuthnTwoFactor(&input)
+	if err != nil {
+		var apiErr *helper.APIError
+		if errors.As(err, &apiErr) {
+			return writeAPIError(c, apiErr)
+		}
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &helper.APIResponse{
+		Status:  helper.APIStatus.Ok,
+		Message: getLoginMessage(userLoginResp),
+		Data:    userLoginResp,
+	})
+}
@@ -0,0 +1,11 @@
+package admin
+
+type CreateClientDto struct {
+	Client struct {
+		Name         string   `json:"name" validate:"required"`
+		RedirectURIs []string `json:"redirectUris" validate:"omitempty,dive,url"`
+		Scopes       []string `json:"scopes" validate:"dive,required"`
+		Public       bool     `json:"public"`
+		GrantTypes   []string `json:"grantTypes" validate:"required,min=1,dive,oneof=authorization_code client_credentials"`
+	} `json:"client" validate:"required"`
+}
@@ -0,0 +1,7 @@
+package admin
+
+type CreateSigningKeyDto struct {
+	SigningKey struct {
+		Algorithm string `json:"algorithm" validate:"required,oneof=HS256 RS256 ES256"`
+	} `json:"signingKey" validate:"required"`
+}
@@ -5,9 +5,42 @@ type UserLoginDto struct {
 		Email    string `json:"email" validate:"required"`
 		Password string `json:"password" validate:"required"`
 	} `json:"user" validate:"required"`
+	Client SessionClientDto `json:"-"`
+}
+
+type TwoFactorLoginDto struct {
+	User struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+		Code           string `json:"code" validate:"required_without=RecoveryCode"`
+		RecoveryCode   string `json:"recoveryCode" validate:"required_without=Code"`
+	} `json:"user" validate:"required"`
+	Client SessionClientDto `json:"-"`
 }
 
 type GoogleLoginDto struct {
 	AuthorizationCode string
-	PathUrl           string
+	State             string
+	StateToken        string
+	Client            SessionClientDto
+}
+
+type ProviderLoginStartDto struct {
+	Provider   string
+	ReturnPath string
+	LinkUserID string
+}
+
+type ProviderLoginDto struct {
+	Provider          string
+	AuthorizationCode string
+	State             string
+	StateToken        string
+	Client            SessionClientDto
+}
+
+type GithubLoginDto struct {
+	AuthorizationCode string
+	State             string
+	StateToken        string
+	Client            SessionClientDto
 }
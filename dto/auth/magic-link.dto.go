@@ -0,0 +1,12 @@
+package auth
+
+type MagicLinkRequestDto struct {
+	User struct {
+		Email string `json:"email" validate:"required,email"`
+	} `json:"user" validate:"required"`
+}
+
+type MagicLinkLoginDto struct {
+	Token  string
+	Client SessionClientDto
+}
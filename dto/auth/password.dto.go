@@ -0,0 +1,8 @@
+package auth
+
+type ResetPasswordDto struct {
+	User struct {
+		Token       string `json:"token" validate:"required"`
+		NewPassword string `json:"newPassword" validate:"required"`
+	} `json:"user" validate:"required"`
+}
@@ -0,0 +1,16 @@
+package auth
+
+// SessionClientDto describes the device which opens a session, it is filled from request headers
+type SessionClientDto struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// LogoutDto carries the claims of the access token used to call logout
+type LogoutDto struct {
+	UserID    string
+	SessionID string
+	TokenID   string
+	ExpiredIn *int64
+}
@@ -1,5 +1,6 @@
 package auth
 
 type RefreshTokenRequestDto struct {
-	RefreshToken string `form:"refreshToken" binding:"required"`
+	RefreshToken string           `form:"refreshToken" binding:"required"`
+	Client       SessionClientDto `form:"-" json:"-"`
 }
@@ -0,0 +1,7 @@
+package auth
+
+type ResendVerificationEmailDto struct {
+	User struct {
+		Email string `json:"email" validate:"required"`
+	} `json:"user" validate:"required"`
+}
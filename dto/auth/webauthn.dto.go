@@ -0,0 +1,39 @@
+package auth
+
+// WebAuthnAssertionDto carries the PublicKeyCredential returned by navigator.credentials.get,
+// binary fields are base64url encoded
+type WebAuthnAssertionDto struct {
+	ID       string `json:"id" validate:"required"`
+	Type     string `json:"type" validate:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+		AuthenticatorData string `json:"authenticatorData" validate:"required"`
+		Signature         string `json:"signature" validate:"required"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response" validate:"required"`
+}
+
+type WebAuthnLoginBeginDto struct {
+	User struct {
+		Email string `json:"email" validate:"omitempty,email"`
+	} `json:"user"`
+}
+
+type WebAuthnLoginDto struct {
+	Credential WebAuthnAssertionDto `json:"credential" validate:"required"`
+	Client     SessionClientDto     `json:"-"`
+}
+
+type WebAuthnTwoFactorBeginDto struct {
+	User struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+	} `json:"user" validate:"required"`
+}
+
+type WebAuthnTwoFactorLoginDto struct {
+	User struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+	} `json:"user" validate:"required"`
+	Credential WebAuthnAssertionDto `json:"credential" validate:"required"`
+	Client     SessionClientDto     `json:"-"`
+}
@@ -0,0 +1,14 @@
+package oauth
+
+type AuthorizeDto struct {
+	ResponseType        string `query:"response_type" form:"response_type" json:"response_type" validate:"required"`
+	ClientID            string `query:"client_id" form:"client_id" json:"client_id" validate:"required"`
+	RedirectURI         string `query:"redirect_uri" form:"redirect_uri" json:"redirect_uri" validate:"required"`
+	Scope               string `query:"scope" form:"scope" json:"scope"`
+	State               string `query:"state" form:"state" json:"state"`
+	Nonce               string `query:"nonce" form:"nonce" json:"nonce"`
+	CodeChallenge       string `query:"code_challenge" form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" form:"code_challenge_method" json:"code_challenge_method"`
+
+	UserID string `query:"-" form:"-" json:"-"`
+}
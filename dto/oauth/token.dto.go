@@ -0,0 +1,11 @@
+package oauth
+
+type TokenDto struct {
+	GrantType    string `form:"grant_type" validate:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
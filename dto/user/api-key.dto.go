@@ -0,0 +1,9 @@
+package user
+
+type CreateAPIKeyDto struct {
+	APIKey struct {
+		Name          string   `json:"name" validate:"required,max=64"`
+		Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+		ExpiredInDays int      `json:"expiredInDays" validate:"omitempty,min=1"`
+	} `json:"apiKey" validate:"required"`
+}
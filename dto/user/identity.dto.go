@@ -0,0 +1,7 @@
+package user
+
+// LinkIdentityDto starts the provider login for the signed in user, the identity is linked by the callback
+type LinkIdentityDto struct {
+	ReturnPath string `json:"returnPath"`
+	Provider   string `json:"-"`
+}
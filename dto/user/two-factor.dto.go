@@ -0,0 +1,7 @@
+package user
+
+type TwoFactorConfirmDto struct {
+	User struct {
+		Code string `json:"code" validate:"required"`
+	} `json:"user" validate:"required"`
+}
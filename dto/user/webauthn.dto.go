@@ -0,0 +1,16 @@
+package user
+
+// WebAuthnRegisterDto carries the PublicKeyCredential returned by navigator.credentials.create,
+// binary fields are base64url encoded
+type WebAuthnRegisterDto struct {
+	Name       string `json:"name" validate:"max=64"`
+	Credential struct {
+		ID       string `json:"id" validate:"required"`
+		Type     string `json:"type" validate:"required,eq=public-key"`
+		Response struct {
+			ClientDataJSON    string   `json:"clientDataJSON" validate:"required"`
+			AttestationObject string   `json:"attestationObject" validate:"required"`
+			Transports        []string `json:"transports"`
+		} `json:"response" validate:"required"`
+	} `json:"credential" validate:"required"`
+}
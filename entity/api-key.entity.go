@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"realworld-authentication/model"
+	"time"
+)
+
+type APIKeyResponse struct {
+	KeyID        string     `json:"keyId"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Key          string     `json:"key,omitempty"`
+	Scopes       []string   `json:"scopes"`
+	CreatedTime  *time.Time `json:"createdTime,omitempty"`
+	LastUsedTime *time.Time `json:"lastUsedTime,omitempty"`
+	ExpiredTime  *time.Time `json:"expiredTime,omitempty"`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []*APIKeyResponse `json:"apiKeys"`
+}
+
+// NewAPIKeyResponse only receives the plain key right after it is created
+func NewAPIKeyResponse(apiKey *model.APIKey, key string) *APIKeyResponse {
+	return &APIKeyResponse{
+		KeyID:        apiKey.KeyID,
+		Name:         apiKey.Name,
+		Prefix:       apiKey.Prefix,
+		Key:          key,
+		Scopes:       apiKey.Scopes,
+		CreatedTime:  apiKey.CreatedTime,
+		LastUsedTime: apiKey.LastUsedTime,
+		ExpiredTime:  apiKey.ExpiredTime,
+	}
+}
+
+func NewAPIKeyListResponse(apiKeys []*model.APIKey) *APIKeyListResponse {
+	resp := new(APIKeyListResponse)
+	resp.APIKeys = make([]*APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		resp.APIKeys = append(resp.APIKeys, NewAPIKeyResponse(apiKey, ""))
+	}
+
+	return resp
+}
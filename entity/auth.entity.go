@@ -1,9 +1,15 @@
 package entity
 
-import "realworld-authentication/model"
+import (
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+)
 
 type UserSignUpResponse struct {
 	User *model.User `json:"user"`
+
+	// set when the password was found in a data breach and the policy only warns
+	PasswordBreachCount int64 `json:"passwordBreachCount,omitempty"`
 }
 
 func NewUserSignupResponse(u *model.User) *UserSignUpResponse {
@@ -14,17 +20,44 @@ func NewUserSignupResponse(u *model.User) *UserSignUpResponse {
 
 type UserLoginResponse struct {
 	User struct {
-		Email       string `json:"email,omitempty"`
-		Username    string `json:"username,omitempty"`
-		AccessToken string `json:"accessToken,omitempty"`
+		Email             string                      `json:"email,omitempty"`
+		Username          string                      `json:"username,omitempty"`
+		AccessToken       string                      `json:"accessToken,omitempty"`
+		RefreshToken      string                      `json:"refreshToken,omitempty"`
+		TwoFactorRequired *bool                       `json:"twoFactorRequired,omitempty"`
+		TwoFactorMethods  []enum.TwoFactorMethodValue `json:"twoFactorMethods,omitempty"`
+		ChallengeToken    string                      `json:"challengeToken,omitempty"`
+
+		PasswordChangeRequired *bool  `json:"passwordChangeRequired,omitempty"`
+		PasswordChangeToken    string `json:"passwordChangeToken,omitempty"`
 	} `json:"user"`
 }
 
-func NewUserLoginResponse(u *model.User) *UserLoginResponse {
+func NewUserLoginResponse(u *model.User, refreshToken string) *UserLoginResponse {
 	resp := new(UserLoginResponse)
 	resp.User.Email = u.Email
 	resp.User.Username = u.Username
 	resp.User.AccessToken = u.AccessToken
+	resp.User.RefreshToken = refreshToken
+	return resp
+}
+
+func NewTwoFactorChallengeResponse(u *model.User, challengeToken string, methods []enum.TwoFactorMethodValue) *UserLoginResponse {
+	resp := new(UserLoginResponse)
+	resp.User.Email = u.Email
+	resp.User.Username = u.Username
+	resp.User.TwoFactorRequired = &enum.TRUE
+	resp.User.TwoFactorMethods = methods
+	resp.User.ChallengeToken = challengeToken
+	return resp
+}
+
+func NewPasswordChangeResponse(u *model.User, passwordChangeToken string) *UserLoginResponse {
+	resp := new(UserLoginResponse)
+	resp.User.Email = u.Email
+	resp.User.Username = u.Username
+	resp.User.PasswordChangeRequired = &enum.TRUE
+	resp.User.PasswordChangeToken = passwordChangeToken
 	return resp
 }
 
@@ -42,14 +75,36 @@ func NewTokenResp(accessToken, refreshToken string) *TokenResponse {
 	return resp
 }
 
-type GoogleOauthTokenResponse struct {
+type SocialLoginResponse struct {
 	Token struct {
 		AccessToken string `json:"accessToken,omitempty"`
 	} `json:"token"`
+	ReturnPath string `json:"-"`
+
+	// set instead of the access token when the login still needs a second factor or a password change
+	TwoFactorMethods    []enum.TwoFactorMethodValue `json:"-"`
+	ChallengeToken      string                      `json:"-"`
+	PasswordChangeToken string                      `json:"-"`
+
+	// set instead of the access token when the callback linked the provider to the signed in user
+	LinkedProvider string `json:"-"`
 }
 
-func NewGoogleOauthTokenResp(accessToken string) *GoogleOauthTokenResponse {
-	resp := new(GoogleOauthTokenResponse)
+type ProviderLoginStartResponse struct {
+	AuthorizationUrl string `json:"authorizationUrl"`
+	StateToken       string `json:"-"`
+}
+
+func NewSocialLoginResp(accessToken string) *SocialLoginResponse {
+	resp := new(SocialLoginResponse)
 	resp.Token.AccessToken = accessToken
 	return resp
 }
+
+func NewSocialLoginRespFromLogin(userLoginResp *UserLoginResponse) *SocialLoginResponse {
+	resp := NewSocialLoginResp(userLoginResp.User.AccessToken)
+	resp.TwoFactorMethods = userLoginResp.User.TwoFactorMethods
+	resp.ChallengeToken = userLoginResp.User.ChallengeToken
+	resp.PasswordChangeToken = userLoginResp.User.PasswordChangeToken
+	return resp
+}
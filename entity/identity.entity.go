@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"realworld-authentication/model"
+	"time"
+)
+
+type IdentityResponse struct {
+	Provider   string     `json:"provider"`
+	Subject    string     `json:"subject"`
+	Email      string     `json:"email,omitempty"`
+	LinkedTime *time.Time `json:"linkedTime,omitempty"`
+}
+
+type IdentityListResponse struct {
+	Identities []*IdentityResponse `json:"identities"`
+}
+
+func NewIdentityListResponse(identities []*model.UserIdentity) *IdentityListResponse {
+	resp := new(IdentityListResponse)
+	resp.Identities = make([]*IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		resp.Identities = append(resp.Identities, &IdentityResponse{
+			Provider:   identity.Provider,
+			Subject:    identity.Subject,
+			Email:      identity.Email,
+			LinkedTime: identity.LinkedTime,
+		})
+	}
+
+	return resp
+}
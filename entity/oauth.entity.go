@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"realworld-authentication/model"
+	"time"
+)
+
+type AuthorizeResponse struct {
+	RedirectURI string `json:"redirectUri"`
+}
+
+// OAuthTokenResponse follows RFC 6749 section 5.1 so standard OAuth clients can read it
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type UserInfoResponse struct {
+	Sub               string `json:"sub"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+}
+
+type OpenIDConfigurationResponse struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+type ClientResponse struct {
+	ClientID     string     `json:"clientId"`
+	ClientSecret string     `json:"clientSecret,omitempty"`
+	Name         string     `json:"name"`
+	RedirectURIs []string   `json:"redirectUris"`
+	Scopes       []string   `json:"scopes"`
+	Public       bool       `json:"public"`
+	GrantTypes   []string   `json:"grantTypes"`
+	CreatedTime  *time.Time `json:"createdTime,omitempty"`
+}
+
+type ClientListResponse struct {
+	Clients []*ClientResponse `json:"clients"`
+}
+
+// NewClientResponse only receives the plain secret right after the client is created
+func NewClientResponse(client *model.Client, clientSecret string) *ClientResponse {
+	grantTypes := make([]string, 0, len(client.GrantTypes))
+	for _, grantType := range client.GrantTypes {
+		grantTypes = append(grantTypes, string(grantType))
+	}
+
+	return &ClientResponse{
+		ClientID:     client.ClientID,
+		ClientSecret: clientSecret,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		Public:       client.Public != nil && *client.Public,
+		GrantTypes:   grantTypes,
+		CreatedTime:  client.CreatedTime,
+	}
+}
+
+func NewClientListResponse(clients []*model.Client) *ClientListResponse {
+	resp := new(ClientListResponse)
+	resp.Clients = make([]*ClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp.Clients = append(resp.Clients, NewClientResponse(client, ""))
+	}
+
+	return resp
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+)
+
+type PasswordRuleFailureResponse struct {
+	Rule    enum.PasswordRuleValue `json:"rule,omitempty"`
+	Message string                 `json:"message,omitempty"`
+}
+
+type PasswordPolicyResponse struct {
+	Password struct {
+		Failures []*PasswordRuleFailureResponse `json:"failures"`
+	} `json:"password"`
+}
+
+func NewPasswordPolicyResponse(failures []*helper.PasswordRuleFailure) *PasswordPolicyResponse {
+	resp := new(PasswordPolicyResponse)
+	resp.Password.Failures = make([]*PasswordRuleFailureResponse, 0, len(failures))
+	for _, failure := range failures {
+		resp.Password.Failures = append(resp.Password.Failures, &PasswordRuleFailureResponse{
+			Rule:    failure.Rule,
+			Message: failure.Message,
+		})
+	}
+	return resp
+}
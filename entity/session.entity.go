@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"realworld-authentication/model"
+	"time"
+)
+
+type SessionResponse struct {
+	SessionID    string     `json:"sessionId,omitempty"`
+	DeviceName   string     `json:"deviceName,omitempty"`
+	UserAgent    string     `json:"userAgent,omitempty"`
+	IPAddress    string     `json:"ipAddress,omitempty"`
+	CreatedTime  *time.Time `json:"createdTime,omitempty"`
+	LastUsedTime *time.Time `json:"lastUsedTime,omitempty"`
+	Current      bool       `json:"current"`
+}
+
+type SessionListResponse struct {
+	Sessions []*SessionResponse `json:"sessions"`
+}
+
+func NewSessionListResponse(sessions []*model.Session, currentSessionID string) *SessionListResponse {
+	resp := new(SessionListResponse)
+	resp.Sessions = make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &SessionResponse{
+			SessionID:    session.SessionID,
+			DeviceName:   session.DeviceName,
+			UserAgent:    session.UserAgent,
+			IPAddress:    session.IPAddress,
+			CreatedTime:  session.CreatedTime,
+			LastUsedTime: session.LastUsedTime,
+			Current:      session.SessionID == currentSessionID,
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+type SigningKeyResponse struct {
+	KeyID           string                     `json:"keyId"`
+	Algorithm       string                     `json:"algorithm"`
+	Status          enum.SigningKeyStatusValue `json:"status"`
+	CreatedTime     *time.Time                 `json:"createdTime,omitempty"`
+	ActivatedTime   *time.Time                 `json:"activatedTime,omitempty"`
+	DeactivatedTime *time.Time                 `json:"deactivatedTime,omitempty"`
+	ExpiredTime     *time.Time                 `json:"expiredTime,omitempty"`
+}
+
+type SigningKeyListResponse struct {
+	SigningKeys []*SigningKeyResponse `json:"signingKeys"`
+}
+
+func NewSigningKeyResponse(key *model.SigningKey) *SigningKeyResponse {
+	return &SigningKeyResponse{
+		KeyID:           key.KeyID,
+		Algorithm:       key.Algorithm,
+		Status:          key.Status,
+		CreatedTime:     key.CreatedTime,
+		ActivatedTime:   key.ActivatedTime,
+		DeactivatedTime: key.DeactivatedTime,
+		ExpiredTime:     key.ExpiredTime,
+	}
+}
+
+func NewSigningKeyListResponse(keys []*model.SigningKey) *SigningKeyListResponse {
+	resp := new(SigningKeyListResponse)
+	resp.SigningKeys = make([]*SigningKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp.SigningKeys = append(resp.SigningKeys, NewSigningKeyResponse(key))
+	}
+
+	return resp
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "realworld-authentication/model/enum"
+
+type TwoFactorSetupResponse struct {
+	TwoFactor struct {
+		Secret          string `json:"secret,omitempty"`
+		ProvisioningURI string `json:"provisioningUri,omitempty"`
+	} `json:"twoFactor"`
+}
+
+func NewTwoFactorSetupResponse(secret, provisioningURI string) *TwoFactorSetupResponse {
+	resp := new(TwoFactorSetupResponse)
+	resp.TwoFactor.Secret = secret
+	resp.TwoFactor.ProvisioningURI = provisioningURI
+	return resp
+}
+
+type TwoFactorStatusResponse struct {
+	TwoFactor struct {
+		Enabled       *bool    `json:"enabled,omitempty"`
+		RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	} `json:"twoFactor"`
+}
+
+func NewTwoFactorEnabledResponse(recoveryCodes []string) *TwoFactorStatusResponse {
+	resp := new(TwoFactorStatusResponse)
+	resp.TwoFactor.Enabled = &enum.TRUE
+	resp.TwoFactor.RecoveryCodes = recoveryCodes
+	return resp
+}
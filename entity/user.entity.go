@@ -22,13 +22,17 @@ type UserPasswordResponse struct {
 		Username          string `json:"username,omitempty"`
 		IsChangedPassword *bool  `json:"isChangedPassword,omitempty"`
 	} `json:"user"`
+
+	// set when the new password was found in a data breach and the policy only warns
+	PasswordBreachCount int64 `json:"passwordBreachCount,omitempty"`
 }
 
-func NewUserPasswordResponse(u *model.User) *UserPasswordResponse {
+func NewUserPasswordResponse(u *model.User, breachCount int64) *UserPasswordResponse {
 	resp := new(UserPasswordResponse)
 	resp.User.Email = u.Email
 	resp.User.Username = u.Username
 	resp.User.IsChangedPassword = &enum.TRUE
+	resp.PasswordBreachCount = breachCount
 
 	return resp
 }
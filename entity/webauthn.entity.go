@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"realworld-authentication/model"
+	"time"
+)
+
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// WebAuthnRegistrationOptionsResponse is passed as is to navigator.credentials.create after decoding the
+// base64url challenge and user id
+type WebAuthnRegistrationOptionsResponse struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		RP        struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"rp"`
+		User struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			DisplayName string `json:"displayName"`
+		} `json:"user"`
+		PubKeyCredParams       []*WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+		Timeout                int64                           `json:"timeout"`
+		Attestation            string                          `json:"attestation"`
+		ExcludeCredentials     []*WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+		AuthenticatorSelection struct {
+			ResidentKey      string `json:"residentKey"`
+			UserVerification string `json:"userVerification"`
+		} `json:"authenticatorSelection"`
+	} `json:"publicKey"`
+}
+
+// WebAuthnLoginOptionsResponse is passed to navigator.credentials.get, an empty allow list lets the
+// authenticator offer its discoverable credentials
+type WebAuthnLoginOptionsResponse struct {
+	PublicKey struct {
+		Challenge        string                          `json:"challenge"`
+		Timeout          int64                           `json:"timeout"`
+		RPID             string                          `json:"rpId"`
+		AllowCredentials []*WebAuthnCredentialDescriptor `json:"allowCredentials"`
+		UserVerification string                          `json:"userVerification"`
+	} `json:"publicKey"`
+}
+
+type WebAuthnCredentialResponse struct {
+	CredentialID string     `json:"credentialId"`
+	Name         string     `json:"name,omitempty"`
+	Transports   []string   `json:"transports,omitempty"`
+	CreatedTime  *time.Time `json:"createdTime,omitempty"`
+	LastUsedTime *time.Time `json:"lastUsedTime,omitempty"`
+}
+
+type WebAuthnCredentialListResponse struct {
+	Credentials []*WebAuthnCredentialResponse `json:"credentials"`
+}
+
+func NewWebAuthnCredentialResponse(credential *model.WebAuthnCredential) *WebAuthnCredentialResponse {
+	return &WebAuthnCredentialResponse{
+		CredentialID: credential.CredentialID,
+		Name:         credential.Name,
+		Transports:   credential.Transports,
+		CreatedTime:  credential.CreatedTime,
+		LastUsedTime: credential.LastUsedTime,
+	}
+}
+
+func NewWebAuthnCredentialListResponse(credentials []*model.WebAuthnCredential) *WebAuthnCredentialListResponse {
+	resp := new(WebAuthnCredentialListResponse)
+	resp.Credentials = make([]*WebAuthnCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		resp.Credentials = append(resp.Credentials, NewWebAuthnCredentialResponse(credential))
+	}
+
+	return resp
+}
+
+func NewWebAuthnCredentialDescriptors(credentials []*model.WebAuthnCredential) []*WebAuthnCredentialDescriptor {
+	descriptors := make([]*WebAuthnCredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		descriptors = append(descriptors, &WebAuthnCredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.CredentialID,
+			Transports: credential.Transports,
+		})
+	}
+
+	return descriptors
+}
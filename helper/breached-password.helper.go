@@ -0,0 +1,91 @@
+package helper
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	BREACHED_PASSWORD_PREFIX_LENGTH = 5
+)
+
+// BreachedPasswordDataset reads a local copy of the HIBP pwned passwords range files, one file per sha1 prefix
+// of 5 hex characters named XXXXX or XXXXX.txt with SUFFIX:COUNT lines, only the file of the prefix is read
+// so the password never leaves the server
+type BreachedPasswordDataset struct {
+	dir string
+}
+
+// LoadBreachedPasswordDataset returns nil when no directory is configured, the check is then turned off
+func LoadBreachedPasswordDataset(dir string) (*BreachedPasswordDataset, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("load breached password dataset: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("load breached password dataset: %s is not a directory", dir)
+	}
+
+	return &BreachedPasswordDataset{
+		dir: dir,
+	}, nil
+}
+
+// Count returns how many times the password appears in the dataset, 0 when it was never breached
+func (d *BreachedPasswordDataset) Count(password string) (int64, error) {
+	if d == nil {
+		return 0, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:BREACHED_PASSWORD_PREFIX_LENGTH], hash[BREACHED_PASSWORD_PREFIX_LENGTH:]
+
+	file, err := d.openRangeFile(prefix)
+	if err != nil {
+		return 0, err
+	}
+	if file == nil {
+		return 0, nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+
+		// padding entries of the range api have a count of 0
+		return strconv.ParseInt(strings.TrimSpace(count), 10, 64)
+	}
+
+	return 0, scanner.Err()
+}
+
+// openRangeFile returns nil when the dataset has no file for the prefix
+func (d *BreachedPasswordDataset) openRangeFile(prefix string) (*os.File, error) {
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		file, err := os.Open(filepath.Join(d.dir, name))
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
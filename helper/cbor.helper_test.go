@@ -0,0 +1,255 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// cborMap keeps the order of its entries, so the encoded bytes are stable
+type cborMap [][2]interface{}
+
+// encodeCBOR is the encoder side of the subset read by DecodeCBOR, tests use it to build authenticator responses
+func encodeCBOR(value interface{}) []byte {
+	switch v := value.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return encodeCBORHead(1, uint64(-1-v))
+		}
+		return encodeCBORHead(0, uint64(v))
+	case []byte:
+		return append(encodeCBORHead(2, uint64(len(v))), v...)
+	case string:
+		return append(encodeCBORHead(3, uint64(len(v))), v...)
+	case []interface{}:
+		out := encodeCBORHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case cborMap:
+		out := encodeCBORHead(5, uint64(len(v)))
+		for _, entry := range v {
+			out = append(out, encodeCBOR(entry[0])...)
+			out = append(out, encodeCBOR(entry[1])...)
+		}
+		return out
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	default:
+		panic("encodeCBOR: unsupported type")
+	}
+}
+
+func encodeCBORHead(major byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{major<<5 | byte(argument)}
+	case argument <= math.MaxUint8:
+		return []byte{major<<5 | 24, byte(argument)}
+	case argument <= math.MaxUint16:
+		head := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(head[1:], uint16(argument))
+		return head
+	case argument <= math.MaxUint32:
+		head := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(argument))
+		return head
+	default:
+		head := []byte{major<<5 | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint64(head[1:], argument)
+		return head
+	}
+}
+
+func mustDecodeHex(t testing.TB, value string) []byte {
+	t.Helper()
+
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// TestDecodeCBOR uses the examples of RFC 8949 appendix A which fall inside the supported subset
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want interface{}
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1a000f4240", int64(1000000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"20", int64(-1)},
+		{"3863", int64(-100)},
+		{"3903e7", int64(-1000)},
+		{"f90000", float64(0)},
+		{"f93c00", float64(1)},
+		{"f9c400", float64(-4)},
+		{"f97bff", float64(65504)},
+		{"f90001", 5.960464477539063e-8},
+		{"fa47c35000", float64(100000)},
+		{"fb3ff199999999999a", 1.1},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"40", []byte{}},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"60", ""},
+		{"6161", "a"},
+		{"62c3bc", "ü"},
+		{"80", []interface{}{}},
+		{"83010203", []interface{}{int64(1), int64(2), int64(3)}},
+		{"8301820203820405", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"a0", map[interface{}]interface{}{}},
+		{"a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[interface{}]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{"c11a514b67b0", int64(1363896240)},
+	}
+
+	for _, tt := range tests {
+		got, rest, err := DecodeCBOR(mustDecodeHex(t, tt.hex))
+		if err != nil {
+			t.Errorf("DecodeCBOR(%s): %v", tt.hex, err)
+			continue
+		}
+		if len(rest) != 0 {
+			t.Errorf("DecodeCBOR(%s) left %d bytes", tt.hex, len(rest))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DecodeCBOR(%s) = %#v, want %#v", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeCBORReturnsRemainingBytes(t *testing.T) {
+	got, rest, err := DecodeCBOR(mustDecodeHex(t, "0102ff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != int64(1) || !bytes.Equal(rest, []byte{0x02, 0xff}) {
+		t.Fatalf("DecodeCBOR() = %v, rest %x", got, rest)
+	}
+}
+
+func TestDecodeCBORRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+	}{
+		{"empty", ""},
+		{"truncated uint8 argument", "18"},
+		{"truncated uint16 argument", "1903"},
+		{"truncated uint32 argument", "1a000f42"},
+		{"truncated uint64 argument", "1b000000e8d4a510"},
+		{"reserved additional information", "1c"},
+		{"integer overflow", "1bffffffffffffffff"},
+		{"negative integer overflow", "3bffffffffffffffff"},
+		{"byte string longer than data", "4501020304"},
+		{"huge byte string length", "5bffffffffffffffff"},
+		{"text string longer than data", "6361"},
+		{"indefinite byte string", "5f4101ff"},
+		{"indefinite array", "9f01ff"},
+		{"indefinite map", "bf616101ff"},
+		{"array longer than data", "830102"},
+		{"huge array length", "9bffffffffffffffff"},
+		{"map longer than data", "a20102"},
+		{"huge map length", "bbffffffffffffffff"},
+		{"map without value", "a101"},
+		{"byte string map key", "a14101f5"},
+		{"array map key", "a18001f5"},
+		{"map map key", "a1a0f5"},
+		{"float map key", "a1f93c00f5"},
+		{"tag without content", "c1"},
+		{"unassigned simple value", "f0"},
+		{"two byte simple value", "f818"},
+		{"truncated half float", "f93c"},
+		{"truncated float", "fa47c350"},
+		{"truncated double", "fb3ff1999999"},
+		{"break without indefinite item", "ff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := DecodeCBOR(mustDecodeHex(t, tt.hex)); err == nil {
+				t.Fatalf("DecodeCBOR(%s) accepted malformed input", tt.hex)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORLimitsNesting(t *testing.T) {
+	nested := append(bytes.Repeat([]byte{0x81}, CBOR_MAX_DEPTH+1), 0x01)
+	if _, _, err := DecodeCBOR(nested); err == nil {
+		t.Fatal("DecodeCBOR() accepted nesting deeper than the limit")
+	}
+
+	tags := append(bytes.Repeat([]byte{0xc1}, CBOR_MAX_DEPTH+1), 0x01)
+	if _, _, err := DecodeCBOR(tags); err == nil {
+		t.Fatal("DecodeCBOR() accepted tags nested deeper than the limit")
+	}
+
+	allowed := append(bytes.Repeat([]byte{0x81}, CBOR_MAX_DEPTH), 0x01)
+	if _, _, err := DecodeCBOR(allowed); err != nil {
+		t.Fatalf("DecodeCBOR() rejected nesting at the limit: %v", err)
+	}
+}
+
+func TestEncodeCBORRoundTrip(t *testing.T) {
+	value := cborMap{
+		{int64(1), int64(2)},
+		{int64(-2), bytes.Repeat([]byte{0xab}, 300)},
+		{"fmt", "none"},
+		{"list", []interface{}{int64(70000), int64(-70000), true, nil}},
+	}
+
+	decoded, rest, err := DecodeCBOR(encodeCBOR(value))
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("DecodeCBOR() err = %v, rest %d", err, len(rest))
+	}
+
+	got, ok := decoded.(map[interface{}]interface{})
+	if !ok || len(got) != len(value) {
+		t.Fatalf("DecodeCBOR() = %#v", decoded)
+	}
+
+	keys := make([]string, 0, len(got))
+	for key := range got {
+		keys = append(keys, reflect.TypeOf(key).String())
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"int64", "int64", "string", "string"}) {
+		t.Fatalf("DecodeCBOR() key types = %v", keys)
+	}
+}
+
+// FuzzDecodeCBOR only checks that no input panics or claims to consume more bytes than it was given
+func FuzzDecodeCBOR(f *testing.F) {
+	for _, seed := range []string{"00", "1bffffffffffffffff", "5bffffffffffffffff", "a26161016162820203", "c11a514b67b0", "f97bff", "9f01ff"} {
+		data, _ := hex.DecodeString(seed)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, rest, err := DecodeCBOR(data)
+		if err == nil && len(rest) > len(data) {
+			t.Fatalf("DecodeCBOR() rest is longer than the input")
+		}
+	})
+}
@@ -1,5 +1,10 @@
 package helper
 
+import (
+	"realworld-authentication/model/enum"
+	"time"
+)
+
 type APIResponse struct {
 	Status    string      `json:"status,omitempty"`
 	Message   string      `json:"message,omitempty"`
@@ -22,3 +27,23 @@ var APIStatus = &apiStatusEnum{
 	"Error",
 	"Notfound",
 }
+
+// APIError carries the error code returned to api callers, RetryAfter is set when the request can be retried later
+// and Data holds the details of the error
+type APIError struct {
+	Code       enum.ErrorCodeEnumValue
+	Message    string
+	RetryAfter time.Duration
+	Data       interface{}
+}
+
+func NewAPIError(code enum.ErrorCodeEnumValue, message string) *APIError {
+	return &APIError{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
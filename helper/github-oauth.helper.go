@@ -0,0 +1,152 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"realworld-authentication/config/env"
+	"strings"
+	"time"
+)
+
+const (
+	GITHUB_PROVIDER = "github"
+)
+
+// GithubHTTPClient sends the requests to github, it can be replaced to reach a stub served over tls
+var GithubHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+type GithubOauthToken struct {
+	AccessToken string
+}
+
+type GithubUserInfo struct {
+	ID    string
+	Login string
+	Name  string
+	Email string
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// GetGithubOauthToken exchanges the code at GithubOauthBaseUrl, the base urls are configurable so the flow
+// can run against a local stub of the github endpoints
+func GetGithubOauthToken(code string) (*GithubOauthToken, error) {
+	rootUrl := strings.TrimSuffix(env.AppConfig.GithubOauthBaseUrl, "/") + "/login/oauth/access_token"
+
+	values := url.Values{}
+	values.Add("code", code)
+	values.Add("client_id", env.AppConfig.GithubOauthClientID)
+	values.Add("client_secret", env.AppConfig.GithubOauthSecret)
+	values.Add("redirect_uri", env.AppConfig.GithubOauthRedirectUrl)
+
+	req, err := http.NewRequest("POST", rootUrl, bytes.NewBufferString(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var githubOauthTokenResp struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := doGithubRequest(req, &githubOauthTokenResp); err != nil {
+		return nil, errors.New("could not retrieve the token")
+	}
+
+	// github answers 200 with an error body when the code is invalid
+	if githubOauthTokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("could not retrieve the token: %s", githubOauthTokenResp.Error)
+	}
+
+	return &GithubOauthToken{
+		AccessToken: githubOauthTokenResp.AccessToken,
+	}, nil
+}
+
+// GetGithubAuthCodeURL builds the github authorization request, github does not issue id tokens so only the
+// state protects the callback
+func GetGithubAuthCodeURL(state string) (string, error) {
+	values := url.Values{}
+	values.Add("client_id", env.AppConfig.GithubOauthClientID)
+	values.Add("redirect_uri", env.AppConfig.GithubOauthRedirectUrl)
+	values.Add("scope", "read:user user:email")
+	values.Add("state", state)
+
+	return AppendQuery(strings.TrimSuffix(env.AppConfig.GithubOauthBaseUrl, "/")+"/login/oauth/authorize", values)
+}
+
+// GetGithubUserInfo returns the profile with the primary email, which is only trusted when github verified it
+func GetGithubUserInfo(accessToken string) (*GithubUserInfo, error) {
+	apiUrl := strings.TrimSuffix(env.AppConfig.GithubApiBaseUrl, "/")
+
+	var githubUserResp struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getGithubResource(apiUrl+"/user", accessToken, &githubUserResp); err != nil {
+		return nil, errors.New("could not retrieve user")
+	}
+
+	var githubEmailsResp []githubEmail
+	if err := getGithubResource(apiUrl+"/user/emails", accessToken, &githubEmailsResp); err != nil {
+		return nil, errors.New("could not retrieve user emails")
+	}
+
+	userInfo := &GithubUserInfo{
+		ID:    fmt.Sprint(githubUserResp.ID),
+		Login: githubUserResp.Login,
+		Name:  githubUserResp.Name,
+	}
+	for _, email := range githubEmailsResp {
+		if email.Primary && email.Verified {
+			userInfo.Email = email.Email
+			break
+		}
+	}
+
+	if userInfo.Email == "" {
+		return nil, errors.New("github account has no verified primary email")
+	}
+
+	return userInfo, nil
+}
+
+func getGithubResource(rootUrl, accessToken string, out interface{}) error {
+	req, err := http.NewRequest("GET", rootUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	return doGithubRequest(req, out)
+}
+
+func doGithubRequest(req *http.Request, out interface{}) error {
+	res, err := GithubHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,207 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"realworld-authentication/config/env"
+	"testing"
+)
+
+const (
+	testGithubCode        = "valid-code"
+	testGithubAccessToken = "gho_test_access_token"
+)
+
+// githubStub answers like the github endpoints used by the login, emails is the body of /user/emails
+type githubStub struct {
+	tokenStatus  int
+	tokenBody    string
+	userStatus   int
+	userBody     string
+	emailsStatus int
+	emailsBody   string
+}
+
+func newGithubStub() *githubStub {
+	return &githubStub{
+		tokenStatus:  http.StatusOK,
+		userStatus:   http.StatusOK,
+		userBody:     `{"id":583231,"login":"octocat","name":"The Octocat"}`,
+		emailsStatus: http.StatusOK,
+		emailsBody:   `[{"email":"old@example.com","primary":false,"verified":true},{"email":"octocat@example.com","primary":true,"verified":true}]`,
+	}
+}
+
+func (s *githubStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/login/oauth/access_token":
+		if r.Method != http.MethodPost || r.Header.Get("Accept") != "application/json" || r.ParseForm() != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("client_id") != "github-client-id" || r.PostForm.Get("client_secret") != "github-client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body := s.tokenBody
+		if body == "" {
+			body = `{"error":"bad_verification_code","error_description":"The code passed is incorrect or expired."}`
+			if r.PostForm.Get("code") == testGithubCode {
+				body = fmt.Sprintf(`{"access_token":%q,"token_type":"bearer","scope":"read:user,user:email"}`, testGithubAccessToken)
+			}
+		}
+		w.WriteHeader(s.tokenStatus)
+		fmt.Fprint(w, body)
+	case "/api/user", "/api/user/emails":
+		if r.Header.Get("Authorization") != "Bearer "+testGithubAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Bad credentials"}`)
+			return
+		}
+
+		if r.URL.Path == "/api/user" {
+			w.WriteHeader(s.userStatus)
+			fmt.Fprint(w, s.userBody)
+			return
+		}
+		w.WriteHeader(s.emailsStatus)
+		fmt.Fprint(w, s.emailsBody)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// startGithubStub points the github base urls of the config at a local server
+func startGithubStub(t *testing.T, stub *githubStub) {
+	t.Helper()
+
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	config := fmt.Sprintf("github_oauth_client_id: github-client-id\ngithub_oauth_secret: github-client-secret\n"+
+		"github_oauth_redirect_url: http://localhost/api/sessions/oauth/github\n"+
+		"github_oauth_base_url: %s/\ngithub_api_base_url: %s/api\n", server.URL, server.URL)
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.LoadConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetGithubOauthToken(t *testing.T) {
+	startGithubStub(t, newGithubStub())
+
+	token, err := GetGithubOauthToken(testGithubCode)
+	if err != nil {
+		t.Fatalf("GetGithubOauthToken(): %v", err)
+	}
+	if token.AccessToken != testGithubAccessToken {
+		t.Fatalf("GetGithubOauthToken() = %s, want %s", token.AccessToken, testGithubAccessToken)
+	}
+}
+
+func TestGetGithubOauthTokenErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  string
+		setup func(stub *githubStub)
+	}{
+		{"invalid code answered with 200", "expired-code", nil},
+		{"server error", testGithubCode, func(stub *githubStub) { stub.tokenStatus = http.StatusInternalServerError }},
+		{"malformed json", testGithubCode, func(stub *githubStub) { stub.tokenBody = `{"access_token":` }},
+		{"empty token", testGithubCode, func(stub *githubStub) { stub.tokenBody = `{}` }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newGithubStub()
+			if tt.setup != nil {
+				tt.setup(stub)
+			}
+			startGithubStub(t, stub)
+
+			if _, err := GetGithubOauthToken(tt.code); err == nil {
+				t.Fatal("GetGithubOauthToken() succeeded")
+			}
+		})
+	}
+}
+
+func TestGetGithubUserInfo(t *testing.T) {
+	startGithubStub(t, newGithubStub())
+
+	userInfo, err := GetGithubUserInfo(testGithubAccessToken)
+	if err != nil {
+		t.Fatalf("GetGithubUserInfo(): %v", err)
+	}
+
+	want := GithubUserInfo{ID: "583231", Login: "octocat", Name: "The Octocat", Email: "octocat@example.com"}
+	if *userInfo != want {
+		t.Fatalf("GetGithubUserInfo() = %+v, want %+v", *userInfo, want)
+	}
+}
+
+func TestGetGithubUserInfoErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		accessToken string
+		setup       func(stub *githubStub)
+	}{
+		{"bad credentials", "gho_revoked", nil},
+		{"user server error", testGithubAccessToken, func(stub *githubStub) { stub.userStatus = http.StatusBadGateway }},
+		{"malformed user", testGithubAccessToken, func(stub *githubStub) { stub.userBody = `[]` }},
+		{"emails forbidden", testGithubAccessToken, func(stub *githubStub) { stub.emailsStatus = http.StatusForbidden }},
+		{"unverified primary email", testGithubAccessToken, func(stub *githubStub) {
+			stub.emailsBody = `[{"email":"octocat@example.com","primary":true,"verified":false}]`
+		}},
+		{"verified email is not primary", testGithubAccessToken, func(stub *githubStub) {
+			stub.emailsBody = `[{"email":"octocat@example.com","primary":false,"verified":true}]`
+		}},
+		{"no email", testGithubAccessToken, func(stub *githubStub) { stub.emailsBody = `[]` }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newGithubStub()
+			if tt.setup != nil {
+				tt.setup(stub)
+			}
+			startGithubStub(t, stub)
+
+			if _, err := GetGithubUserInfo(tt.accessToken); err == nil {
+				t.Fatal("GetGithubUserInfo() succeeded")
+			}
+		})
+	}
+}
+
+func TestGetGithubAuthCodeURL(t *testing.T) {
+	startGithubStub(t, newGithubStub())
+
+	authorizationUrl, err := GetGithubAuthCodeURL("state-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(authorizationUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/login/oauth/authorize" {
+		t.Fatalf("GetGithubAuthCodeURL() path = %s", parsed.Path)
+	}
+
+	query := parsed.Query()
+	if query.Get("client_id") != "github-client-id" || query.Get("state") != "state-value" || query.Get("redirect_uri") != env.AppConfig.GithubOauthRedirectUrl {
+		t.Fatalf("GetGithubAuthCodeURL() query = %v", query)
+	}
+}
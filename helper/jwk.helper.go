@@ -0,0 +1,371 @@
+package helper
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"realworld-authentication/config/env"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	RSA_KEY_BITS      = 2048
+	HMAC_SECRET_BYTES = 32
+)
+
+type SigningKey struct {
+	KeyID     string
+	Method    jwt.SigningMethod
+	SignKey   interface{}
+	VerifyKey interface{}
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// KeySet holds the key used to sign new access tokens and every key still accepted for verification
+type KeySet struct {
+	mu          sync.RWMutex
+	activeKeyID string
+	keys        map[string]*SigningKey
+}
+
+var AccessTokenKeySet = &KeySet{}
+
+func (ks *KeySet) SetKeys(active *SigningKey, verifyOnly ...*SigningKey) {
+	keys := map[string]*SigningKey{active.KeyID: active}
+	for _, key := range verifyOnly {
+		keys[key.KeyID] = key
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.activeKeyID = active.KeyID
+	ks.keys = keys
+}
+
+func (ks *KeySet) ActiveKey() (*SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[ks.activeKeyID]
+	if !ok {
+		return nil, errors.New("signing key is not loaded")
+	}
+
+	return key, nil
+}
+
+func (ks *KeySet) Key(kid string) (*SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) Keys() []*SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// PublicJWKS returns the public part of asymmetric keys, hmac keys are never published
+func (ks *KeySet) PublicJWKS() *JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	jwks := &JWKS{Keys: []*JWK{}}
+	for _, key := range ks.keys {
+		if jwk := key.PublicJWK(); jwk != nil {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
+
+// LoadConfigKeySet builds ks from the access token settings in config, when an asymmetric key is configured the
+// hmac AccessTokenKey stays verification-only so tokens issued before the switch keep working until they expire
+func LoadConfigKeySet(ks *KeySet) error {
+	method := env.AppConfig.AccessTokenSigningMethod
+	if method == "" || method == jwt.SigningMethodHS256.Alg() {
+		ks.SetKeys(NewHMACSigningKey(env.AppConfig.AccessTokenKey))
+		return nil
+	}
+
+	signingKey, err := LoadSigningKeyFromPEM(method, env.AppConfig.AccessTokenPrivateKeyPath)
+	if err != nil {
+		return err
+	}
+
+	if env.AppConfig.AccessTokenKey == "" {
+		ks.SetKeys(signingKey)
+		return nil
+	}
+
+	ks.SetKeys(signingKey, NewHMACSigningKey(env.AppConfig.AccessTokenKey))
+	return nil
+}
+
+func NewHMACSigningKey(secret string) *SigningKey {
+	sum := sha256.Sum256([]byte(secret))
+	return &SigningKey{
+		KeyID:     base64.RawURLEncoding.EncodeToString(sum[:8]),
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   []byte(secret),
+		VerifyKey: []byte(secret),
+	}
+}
+
+// GenerateSigningKey creates a fresh key for HS256, RS256 or ES256
+func GenerateSigningKey(method string) (*SigningKey, error) {
+	switch method {
+	case jwt.SigningMethodHS256.Alg():
+		secret, err := GenerateRandomToken(HMAC_SECRET_BYTES)
+		if err != nil {
+			return nil, err
+		}
+		return NewHMACSigningKey(secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		privateKey, err := rsa.GenerateKey(rand.Reader, RSA_KEY_BITS)
+		if err != nil {
+			return nil, fmt.Errorf("generate rsa key: %w", err)
+		}
+		return newAsymmetricSigningKey(jwt.SigningMethodRS256, privateKey, &privateKey.PublicKey)
+	case jwt.SigningMethodES256.Alg():
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate ecdsa key: %w", err)
+		}
+		return newAsymmetricSigningKey(jwt.SigningMethodES256, privateKey, &privateKey.PublicKey)
+	default:
+		return nil, fmt.Errorf("unsupported signing method: %s", method)
+	}
+}
+
+// MarshalSigningKey returns the secret for hmac keys and the PEM encoded private key otherwise,
+// the result is read back by ParseSigningKey
+func MarshalSigningKey(key *SigningKey) (string, error) {
+	switch privateKey := key.SignKey.(type) {
+	case []byte:
+		return string(privateKey), nil
+	case *rsa.PrivateKey:
+		return string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		})), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: der,
+		})), nil
+	default:
+		return "", errors.New("unsupported signing key type")
+	}
+}
+
+func ParseSigningKey(method, material string) (*SigningKey, error) {
+	if method == jwt.SigningMethodHS256.Alg() {
+		return NewHMACSigningKey(material), nil
+	}
+
+	return ParseSigningKeyFromPEM(method, []byte(material))
+}
+
+// LoadSigningKeyFromPEM reads a RS256 or ES256 private key, the kid is the RFC 7638 thumbprint of the public key
+func LoadSigningKeyFromPEM(method, path string) (*SigningKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+
+	return ParseSigningKeyFromPEM(method, pemBytes)
+}
+
+func ParseSigningKeyFromPEM(method string, pemBytes []byte) (*SigningKey, error) {
+	switch method {
+	case jwt.SigningMethodRS256.Alg():
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse rsa key: %w", err)
+		}
+		return newAsymmetricSigningKey(jwt.SigningMethodRS256, privateKey, &privateKey.PublicKey)
+	case jwt.SigningMethodES256.Alg():
+		privateKey, err := jwt.ParseECPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse ecdsa key: %w", err)
+		}
+		if privateKey.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+		return newAsymmetricSigningKey(jwt.SigningMethodES256, privateKey, &privateKey.PublicKey)
+	default:
+		return nil, fmt.Errorf("unsupported signing method: %s", method)
+	}
+}
+
+func newAsymmetricSigningKey(method jwt.SigningMethod, signKey, verifyKey interface{}) (*SigningKey, error) {
+	key := &SigningKey{
+		Method:    method,
+		SignKey:   signKey,
+		VerifyKey: verifyKey,
+	}
+
+	thumbprint, err := key.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	key.KeyID = thumbprint
+
+	return key, nil
+}
+
+// IsAsymmetric tells whether relying parties can verify the key through the JWKS, an hmac secret is never published
+func (k *SigningKey) IsAsymmetric() bool {
+	return k.PublicJWK() != nil
+}
+
+func (k *SigningKey) PublicJWK() *JWK {
+	switch publicKey := k.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			Kid: k.KeyID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			Kty: "EC",
+			Kid: k.KeyID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Crv: publicKey.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, size))),
+		}
+	default:
+		return nil
+	}
+}
+
+// PublicKey converts a published RSA or EC key back to the key used to verify signatures
+func (j *JWK) PublicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode exponent: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+		publicKey := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", j.Kty)
+	}
+}
+
+// Thumbprint hashes the required public members in lexicographic order (RFC 7638)
+func (k *SigningKey) Thumbprint() (string, error) {
+	jwk := k.PublicJWK()
+	if jwk == nil {
+		return "", errors.New("thumbprint is only defined for asymmetric keys")
+	}
+
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
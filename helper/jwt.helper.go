@@ -1,34 +1,208 @@
 package helper
 
 import (
+	"errors"
 	"fmt"
+	"realworld-authentication/config/env"
+	"realworld-authentication/model/enum"
 	"realworld-authentication/utils"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+const (
+	TOKEN_ID_BYTES = 16
+)
+
 type TokenDetails struct {
 	Token     *string
+	TokenID   string
 	UserID    string
+	SessionID string
+	ClientID  string
+	Scope     string
+	GrantType string
+	TokenUse  enum.TokenUseValue
+	Audience  string
+	Email     string
 	ExpiredIn *int64
+	IssuedAt  *int64
+}
+
+// CallerType is client for tokens from the client credentials grant, they are not bound to any user
+func (t *TokenDetails) CallerType() enum.CallerTypeValue {
+	if t.GrantType == string(enum.OAuthGrantType.ClientCredentials) {
+		return enum.CallerType.Client
+	}
+
+	return enum.CallerType.User
 }
 
 func GenerateJWT(userId string, ttl time.Duration, tokenKey string) (*TokenDetails, error) {
-	// gen access token
-	expirationTime := time.Now().Add(ttl * time.Minute).Unix()
-	tokenDetails := &TokenDetails{
+	return generateJWT(&TokenDetails{
+		UserID: userId,
+	}, ttl, NewHMACSigningKey(tokenKey))
+}
+
+// GenerateEmailVerificationJWT puts the address into the "email" claim, the link only verifies the email it was sent to
+func GenerateEmailVerificationJWT(userId, email string, ttl time.Duration, tokenKey string) (*TokenDetails, error) {
+	return generateJWT(&TokenDetails{
+		UserID: userId,
+		Email:  email,
+	}, ttl, NewHMACSigningKey(tokenKey))
+}
+
+// GenerateSessionJWT puts the session into the "sid" claim, all tokens rotated from one login share the session
+func GenerateSessionJWT(userId, sessionID string, ttl time.Duration, tokenKey string) (*TokenDetails, error) {
+	return generateJWT(&TokenDetails{
 		UserID:    userId,
-		ExpiredIn: &expirationTime,
+		SessionID: sessionID,
+	}, ttl, NewHMACSigningKey(tokenKey))
+}
+
+// GenerateAccessJWT signs with the active key of AccessTokenKeySet, which may be asymmetric
+func GenerateAccessJWT(userId, sessionID string, ttl time.Duration) (*TokenDetails, error) {
+	signingKey, err := AccessTokenKeySet.ActiveKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return generateJWT(&TokenDetails{
+		UserID:    userId,
+		SessionID: sessionID,
+		TokenUse:  enum.TokenUse.Access,
+		Audience:  env.AppConfig.AccessTokenAudience,
+	}, ttl, signingKey)
+}
+
+// GenerateOAuthAccessJWT issues an access token to a registered client, the granted scope is kept in the "scope" claim
+func GenerateOAuthAccessJWT(userId, clientID, scope string, ttl time.Duration) (*TokenDetails, error) {
+	signingKey, err := AccessTokenKeySet.ActiveKey()
+	if err != nil {
+		return nil, err
 	}
 
+	return generateJWT(&TokenDetails{
+		UserID:   userId,
+		ClientID: clientID,
+		Scope:    scope,
+		TokenUse: enum.TokenUse.OAuthAccess,
+		Audience: env.AppConfig.AccessTokenAudience,
+	}, ttl, signingKey)
+}
+
+// GenerateClientAccessJWT issues a token for the client itself, "sub" is the client id and "gty" marks the grant
+func GenerateClientAccessJWT(clientID, scope string, ttl time.Duration) (*TokenDetails, error) {
+	signingKey, err := AccessTokenKeySet.ActiveKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return generateJWT(&TokenDetails{
+		UserID:    clientID,
+		ClientID:  clientID,
+		Scope:     scope,
+		GrantType: string(enum.OAuthGrantType.ClientCredentials),
+		TokenUse:  enum.TokenUse.ClientAccess,
+		Audience:  env.AppConfig.AccessTokenAudience,
+	}, ttl, signingKey)
+}
+
+// GenerateIDToken signs the OpenID Connect claims with the active access token key, so relying parties
+// verify it through the JWKS endpoint. Its "aud" is the client and "token_use" marks it, so the api never takes
+// it as an access token. An hmac key is refused, relying parties could not verify it and it must not leave
+// the server
+func GenerateIDToken(claims map[string]interface{}, ttl time.Duration) (string, error) {
+	signingKey, err := IDTokenSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	tokenID, err := GenerateRandomToken(TOKEN_ID_BYTES)
+	if err != nil {
+		return "", fmt.Errorf("create token id: %w", err)
+	}
+
+	now := time.Now()
+	idClaims := jwt.MapClaims{}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+	idClaims["jti"] = tokenID
+	idClaims["token_use"] = enum.TokenUse.ID
+	idClaims["iat"] = now.Unix()
+	idClaims["exp"] = now.Add(ttl * time.Minute).Unix()
+
+	token := jwt.NewWithClaims(signingKey.Method, idClaims)
+	token.Header["kid"] = signingKey.KeyID
+
+	tokenString, err := token.SignedString(signingKey.SignKey)
+	if err != nil {
+		return "", fmt.Errorf("create sign token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// IDTokenSigningKey returns the active access token key when it can sign id tokens
+func IDTokenSigningKey() (*SigningKey, error) {
+	signingKey, err := AccessTokenKeySet.ActiveKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if !signingKey.IsAsymmetric() {
+		return nil, errors.New("openid connect requires an asymmetric access token signing key")
+	}
+
+	return signingKey, nil
+}
+
+func generateJWT(tokenDetails *TokenDetails, ttl time.Duration, signingKey *SigningKey) (*TokenDetails, error) {
+	// jti identifies the token in the revocation list
+	tokenID, err := GenerateRandomToken(TOKEN_ID_BYTES)
+	if err != nil {
+		return nil, fmt.Errorf("create token id: %w", err)
+	}
+
+	expirationTime := time.Now().Add(ttl * time.Minute).Unix()
+	tokenDetails.TokenID = tokenID
+	tokenDetails.ExpiredIn = &expirationTime
+
 	now := utils.GetCurrentTimeZoneVN()
 	atClaims := make(jwt.MapClaims)
 	atClaims["sub"] = tokenDetails.UserID
+	atClaims["jti"] = tokenDetails.TokenID
 	atClaims["exp"] = tokenDetails.ExpiredIn
 	atClaims["iat"] = now.Unix()
+	if tokenDetails.SessionID != "" {
+		atClaims["sid"] = tokenDetails.SessionID
+	}
+	if tokenDetails.ClientID != "" {
+		atClaims["client_id"] = tokenDetails.ClientID
+	}
+	if tokenDetails.Scope != "" {
+		atClaims["scope"] = tokenDetails.Scope
+	}
+	if tokenDetails.GrantType != "" {
+		atClaims["gty"] = tokenDetails.GrantType
+	}
+	if tokenDetails.TokenUse != "" {
+		atClaims["token_use"] = tokenDetails.TokenUse
+	}
+	if tokenDetails.Audience != "" {
+		atClaims["aud"] = tokenDetails.Audience
+	}
+	if tokenDetails.Email != "" {
+		atClaims["email"] = tokenDetails.Email
+	}
 
-	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims).SignedString([]byte(tokenKey))
+	token := jwt.NewWithClaims(signingKey.Method, atClaims)
+	token.Header["kid"] = signingKey.KeyID
+
+	tokenString, err := token.SignedString(signingKey.SignKey)
 	if err != nil {
 		return nil, fmt.Errorf("create sign token: %w", err)
 	}
@@ -38,13 +212,69 @@ func GenerateJWT(userId string, ttl time.Duration, tokenKey string) (*TokenDetai
 }
 
 func ValidateToken(token string, tokenKey string) (*TokenDetails, error) {
-	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+	return validateJWT(token, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected method: %s", t.Header["alg"])
 		}
 		return []byte(tokenKey), nil
 	})
+}
+
+// ValidateAccessToken picks the verification key by the "kid" header, tokens issued before kid was added
+// fall back to the active key. Id tokens and tokens of another kind or audience share the keys, so "token_use"
+// and "aud" must match as well. Client access tokens are only accepted with the client credentials "gty"
+func ValidateAccessToken(token string, tokenUses ...enum.TokenUseValue) (*TokenDetails, error) {
+	tokenDetails, err := validateJWT(token, func(t *jwt.Token) (interface{}, error) {
+		var signingKey *SigningKey
+		if kid, ok := t.Header["kid"].(string); ok {
+			key, found := AccessTokenKeySet.Key(kid)
+			if !found {
+				return nil, fmt.Errorf("unknown key id: %s", kid)
+			}
+			signingKey = key
+		} else {
+			key, err := AccessTokenKeySet.ActiveKey()
+			if err != nil {
+				return nil, err
+			}
+			signingKey = key
+		}
+
+		// the alg header must match the key, otherwise a public key could be used as hmac secret
+		if t.Method.Alg() != signingKey.Method.Alg() {
+			return nil, fmt.Errorf("unexpected method: %s", t.Header["alg"])
+		}
+		return signingKey.VerifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasTokenUse(tokenUses, tokenDetails.TokenUse) {
+		return nil, fmt.Errorf("validate: token is not %s token", strings.ReplaceAll(string(tokenUses[0]), "_", " "))
+	}
+	if (tokenDetails.TokenUse == enum.TokenUse.ClientAccess) != (tokenDetails.CallerType() == enum.CallerType.Client) {
+		return nil, fmt.Errorf("validate: token grant does not match its use")
+	}
+	if tokenDetails.Audience != env.AppConfig.AccessTokenAudience {
+		return nil, fmt.Errorf("validate: token audience is not accepted")
+	}
+
+	return tokenDetails, nil
+}
+
+func hasTokenUse(tokenUses []enum.TokenUseValue, tokenUse enum.TokenUseValue) bool {
+	for _, value := range tokenUses {
+		if value == tokenUse {
+			return true
+		}
+	}
+
+	return false
+}
 
+func validateJWT(token string, keyFunc jwt.Keyfunc) (*TokenDetails, error) {
+	parsedToken, err := jwt.Parse(token, keyFunc)
 	if err != nil {
 		return nil, fmt.Errorf("validate: %w", err)
 	}
@@ -54,7 +284,41 @@ func ValidateToken(token string, tokenKey string) (*TokenDetails, error) {
 		return nil, fmt.Errorf("validate: invalid token")
 	}
 
-	return &TokenDetails{
+	tokenDetails := &TokenDetails{
 		UserID: fmt.Sprint(claims["sub"]),
-	}, nil
+	}
+	if tokenID, ok := claims["jti"].(string); ok {
+		tokenDetails.TokenID = tokenID
+	}
+	if sessionID, ok := claims["sid"].(string); ok {
+		tokenDetails.SessionID = sessionID
+	}
+	if clientID, ok := claims["client_id"].(string); ok {
+		tokenDetails.ClientID = clientID
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		tokenDetails.Scope = scope
+	}
+	if grantType, ok := claims["gty"].(string); ok {
+		tokenDetails.GrantType = grantType
+	}
+	if tokenUse, ok := claims["token_use"].(string); ok {
+		tokenDetails.TokenUse = enum.TokenUseValue(tokenUse)
+	}
+	if audience, ok := claims["aud"].(string); ok {
+		tokenDetails.Audience = audience
+	}
+	if email, ok := claims["email"].(string); ok {
+		tokenDetails.Email = email
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		expiredIn := int64(exp)
+		tokenDetails.ExpiredIn = &expiredIn
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt := int64(iat)
+		tokenDetails.IssuedAt = &issuedAt
+	}
+
+	return tokenDetails, nil
 }
@@ -0,0 +1,146 @@
+package helper
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"realworld-authentication/config/env"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	OAUTH_STATE_BYTES = 32
+	OAUTH_NONCE_BYTES = 16
+)
+
+// OAuthState is kept in a signed cookie between the start of a social login and the provider callback
+type OAuthState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ReturnPath   string
+
+	// set when a signed in user links the provider instead of logging in with it
+	LinkUserID string
+}
+
+// GenerateOAuthState returns the state with the signed token stored by the browser, the State value alone
+// is sent to the provider and has to come back with the token of the same browser
+func GenerateOAuthState(provider, returnPath, linkUserID string) (*OAuthState, string, error) {
+	state, err := GenerateRandomToken(OAUTH_STATE_BYTES)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nonce, err := GenerateRandomToken(OAUTH_NONCE_BYTES)
+	if err != nil {
+		return nil, "", err
+	}
+
+	codeVerifier, err := GeneratePKCEVerifier()
+	if err != nil {
+		return nil, "", err
+	}
+
+	oauthState := &OAuthState{
+		State:        state,
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ReturnPath:   returnPath,
+		LinkUserID:   linkUserID,
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"state":         oauthState.State,
+		"provider":      oauthState.Provider,
+		"nonce":         oauthState.Nonce,
+		"code_verifier": oauthState.CodeVerifier,
+		"return_path":   oauthState.ReturnPath,
+		"link_user_id":  oauthState.LinkUserID,
+		"iat":           now.Unix(),
+		"exp":           now.Add(env.AppConfig.OAuthStateExpiredIn * time.Minute).Unix(),
+	})
+
+	stateToken, err := token.SignedString([]byte(env.AppConfig.OAuthStateKey))
+	if err != nil {
+		return nil, "", fmt.Errorf("sign oauth state: %w", err)
+	}
+
+	return oauthState, stateToken, nil
+}
+
+// ValidateOAuthState checks the cookie token and that the provider returned the state it was started with
+func ValidateOAuthState(stateToken, state, provider string) (*OAuthState, error) {
+	if stateToken == "" || state == "" {
+		return nil, errors.New("oauth state is missing")
+	}
+
+	parsedToken, err := jwt.Parse(stateToken, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected method: %s", t.Header["alg"])
+		}
+		return []byte(env.AppConfig.OAuthStateKey), nil
+	})
+	if err != nil {
+		return nil, errors.New("oauth state is invalid or expired")
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, errors.New("oauth state is invalid or expired")
+	}
+
+	oauthState := &OAuthState{
+		State:        claimString(claims, "state"),
+		Provider:     claimString(claims, "provider"),
+		Nonce:        claimString(claims, "nonce"),
+		CodeVerifier: claimString(claims, "code_verifier"),
+		ReturnPath:   claimString(claims, "return_path"),
+		LinkUserID:   claimString(claims, "link_user_id"),
+	}
+
+	if subtle.ConstantTimeCompare([]byte(oauthState.State), []byte(state)) != 1 {
+		return nil, errors.New("oauth state does not match")
+	}
+
+	if oauthState.Provider != strings.ToLower(provider) {
+		return nil, errors.New("oauth state belongs to another provider")
+	}
+
+	return oauthState, nil
+}
+
+// IsAllowedReturnPath only accepts relative paths listed in oauth_return_paths, so the login cannot be used
+// to redirect the browser to another site
+func IsAllowedReturnPath(returnPath string) bool {
+	if !strings.HasPrefix(returnPath, "/") || strings.HasPrefix(returnPath, "//") || strings.Contains(returnPath, "\\") {
+		return false
+	}
+
+	parsedPath, err := url.Parse(returnPath)
+	if err != nil || parsedPath.Host != "" || parsedPath.Scheme != "" {
+		return false
+	}
+
+	for _, allowedPath := range env.AppConfig.OAuthReturnPaths {
+		if strings.HasSuffix(allowedPath, "*") {
+			if strings.HasPrefix(parsedPath.Path, strings.TrimSuffix(allowedPath, "*")) {
+				return true
+			}
+			continue
+		}
+
+		if parsedPath.Path == allowedPath {
+			return true
+		}
+	}
+
+	return false
+}
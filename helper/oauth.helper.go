@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"net/url"
+	"realworld-authentication/model/enum"
+	"strings"
+)
+
+// OAuthError carries the error code returned to OAuth clients, its message is the error_description
+type OAuthError struct {
+	Code        enum.OAuthErrorCodeValue
+	Description string
+}
+
+func NewOAuthError(code enum.OAuthErrorCodeValue, description string) *OAuthError {
+	return &OAuthError{
+		Code:        code,
+		Description: description,
+	}
+}
+
+func (e *OAuthError) Error() string {
+	return e.Description
+}
+
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+func HasScope(scope string, expected enum.OAuthScopeValue) bool {
+	for _, s := range ParseScope(scope) {
+		if s == string(expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AppendQuery adds values to the query of rawURL, the parameters already registered in the url are kept
+func AppendQuery(rawURL string, values url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for key, value := range values {
+		for _, v := range value {
+			query.Add(key, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,380 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"realworld-authentication/config/env"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	GOOGLE_PROVIDER = "google"
+	GOOGLE_ISSUER   = "https://accounts.google.com"
+
+	// an id token with an unknown kid reloads the JWKS at most once in this interval
+	OIDC_JWKS_REFRESH_INTERVAL = time.Minute
+)
+
+type OIDCDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type OIDCTokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+// OIDCIdentity is the user read from a verified id token through the provider claim mapping
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+}
+
+type OIDCProvider struct {
+	Name   string
+	config env.OAuthProviderConfig
+
+	mu        sync.RWMutex
+	discovery *OIDCDiscovery
+	keys      map[string]interface{}
+
+	refreshMu      sync.Mutex
+	keysLoadedTime time.Time
+}
+
+type OIDCProviderRegistry struct {
+	providers map[string]*OIDCProvider
+}
+
+// NewOIDCProviderRegistry registers every provider of oauth_providers, google falls back to the
+// google_oauth_* settings when it is not listed there
+func NewOIDCProviderRegistry(configs map[string]env.OAuthProviderConfig) *OIDCProviderRegistry {
+	registry := &OIDCProviderRegistry{
+		providers: map[string]*OIDCProvider{},
+	}
+
+	for name, config := range configs {
+		registry.providers[strings.ToLower(name)] = NewOIDCProvider(strings.ToLower(name), config)
+	}
+
+	if _, ok := registry.providers[GOOGLE_PROVIDER]; !ok && env.AppConfig.GoogleOauthClientID != "" {
+		registry.providers[GOOGLE_PROVIDER] = NewOIDCProvider(GOOGLE_PROVIDER, env.OAuthProviderConfig{
+			Issuer:       GOOGLE_ISSUER,
+			ClientID:     env.AppConfig.GoogleOauthClientID,
+			ClientSecret: env.AppConfig.GoogleOauthSecret,
+			RedirectUrl:  env.AppConfig.GoogleOauthRedirectUrl,
+		})
+	}
+
+	return registry
+}
+
+func (r *OIDCProviderRegistry) GetProvider(name string) (*OIDCProvider, error) {
+	provider, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("login provider %s is not configured", name)
+	}
+
+	return provider, nil
+}
+
+func NewOIDCProvider(name string, config env.OAuthProviderConfig) *OIDCProvider {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	mapping := &config.ClaimMapping
+	for field, claim := range map[*string]string{
+		&mapping.Subject:       "sub",
+		&mapping.Email:         "email",
+		&mapping.EmailVerified: "email_verified",
+		&mapping.Username:      "preferred_username",
+		&mapping.Name:          "name",
+	} {
+		if *field == "" {
+			*field = claim
+		}
+	}
+
+	return &OIDCProvider{
+		Name:   name,
+		config: config,
+	}
+}
+
+// AuthCodeURL builds the authorization request sent to the provider
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	values.Add("response_type", "code")
+	values.Add("client_id", p.config.ClientID)
+	values.Add("redirect_uri", p.config.RedirectUrl)
+	values.Add("scope", strings.Join(p.config.Scopes, " "))
+	values.Add("state", state)
+	if nonce != "" {
+		values.Add("nonce", nonce)
+	}
+	if codeChallenge != "" {
+		values.Add("code_challenge", codeChallenge)
+		values.Add("code_challenge_method", PKCE_METHOD_S256)
+	}
+
+	return AppendQuery(discovery.AuthorizationEndpoint, values)
+}
+
+func (p *OIDCProvider) Exchange(code, codeVerifier string) (*OIDCTokens, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Add("grant_type", "authorization_code")
+	values.Add("code", code)
+	values.Add("client_id", p.config.ClientID)
+	values.Add("client_secret", p.config.ClientSecret)
+	values.Add("redirect_uri", p.config.RedirectUrl)
+	if codeVerifier != "" {
+		values.Add("code_verifier", codeVerifier)
+	}
+
+	req, err := http.NewRequest("POST", discovery.TokenEndpoint, bytes.NewBufferString(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens OIDCTokens
+	if err := doOIDCRequest(req, &tokens); err != nil {
+		return nil, errors.New("could not retrieve the token")
+	}
+
+	if tokens.IDToken == "" {
+		return nil, errors.New("provider did not return an id token")
+	}
+
+	return &tokens, nil
+}
+
+// VerifyIDToken checks signature against the provider JWKS, issuer, audience, expiry and nonce,
+// only asymmetric algorithms are accepted
+func (p *OIDCProvider) VerifyIDToken(rawIDToken, nonce string) (*OIDCIdentity, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	parsedToken, err := jwt.Parse(rawIDToken, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected method: %s", t.Header["alg"])
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return p.getVerifyKey(discovery.JwksURI, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify id token: %w", err)
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, errors.New("verify id token: invalid token")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != discovery.Issuer {
+		return nil, errors.New("verify id token: unexpected issuer")
+	}
+
+	if !hasAudience(claims["aud"], p.config.ClientID) {
+		return nil, errors.New("verify id token: unexpected audience")
+	}
+
+	if nonce != "" {
+		if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+			return nil, errors.New("verify id token: nonce does not match")
+		}
+	}
+
+	mapping := p.config.ClaimMapping
+	identity := &OIDCIdentity{
+		Provider: p.Name,
+		Subject:  claimString(claims, mapping.Subject),
+		Email:    strings.ToLower(claimString(claims, mapping.Email)),
+		Username: claimString(claims, mapping.Username),
+		Name:     claimString(claims, mapping.Name),
+	}
+
+	// some providers send email_verified as a string
+	switch verified := claims[mapping.EmailVerified].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("verify id token: missing subject")
+	}
+
+	return identity, nil
+}
+
+func (p *OIDCProvider) getDiscovery() (*OIDCDiscovery, error) {
+	p.mu.RLock()
+	discovery := p.discovery
+	p.mu.RUnlock()
+
+	if discovery != nil {
+		return discovery, nil
+	}
+
+	req, err := http.NewRequest("GET", p.config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery = &OIDCDiscovery{}
+	if err := doOIDCRequest(req, discovery); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", p.Name, err)
+	}
+
+	// the document must describe the configured issuer, otherwise tokens of another issuer would be accepted
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("discover %s: issuer mismatch", p.Name)
+	}
+
+	p.mu.Lock()
+	p.discovery = discovery
+	p.mu.Unlock()
+
+	return discovery, nil
+}
+
+// getVerifyKey serves keys from cache and reloads the JWKS once when the kid is unknown, which happens
+// after the provider rotates its keys. Reloads are serialized and rate limited, so tokens with made up kids
+// cannot make the server fetch the JWKS on every request
+func (p *OIDCProvider) getVerifyKey(jwksURI, kid string) (interface{}, error) {
+	if key, ok := p.getCachedKey(kid); ok {
+		return key, nil
+	}
+
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	// another request may have reloaded the keys while this one waited
+	if key, ok := p.getCachedKey(kid); ok {
+		return key, nil
+	}
+	if !p.keysLoadedTime.IsZero() && time.Since(p.keysLoadedTime) < OIDC_JWKS_REFRESH_INTERVAL {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	p.keysLoadedTime = time.Now()
+
+	req, err := http.NewRequest("GET", jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks JWKS
+	if err := doOIDCRequest(req, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = publicKey
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+
+	return key, nil
+}
+
+func (p *OIDCProvider) getCachedKey(kid string) (interface{}, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == clientID
+	case []interface{}:
+		for _, v := range value {
+			if s, ok := v.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	value, ok := claims[name]
+	if !ok || value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+func doOIDCRequest(req *http.Request, out interface{}) error {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
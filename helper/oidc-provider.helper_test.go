@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetVerifyKeyRateLimitsJWKSReload(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"keys":[]}`)
+	}))
+	defer server.Close()
+
+	provider := &OIDCProvider{Name: "test"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := provider.getVerifyKey(server.URL, fmt.Sprintf("made-up-kid-%d", i)); err == nil {
+				t.Error("getVerifyKey() accepted an unknown kid")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	// once the interval has passed an unknown kid reloads the keys again
+	provider.keysLoadedTime = time.Now().Add(-OIDC_JWKS_REFRESH_INTERVAL)
+	if _, err := provider.getVerifyKey(server.URL, "rotated-kid"); err == nil {
+		t.Fatal("getVerifyKey() accepted an unknown kid")
+	}
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Fatalf("JWKS fetched %d times after the interval, want 2", got)
+	}
+}
@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"fmt"
+	"realworld-authentication/config/env"
+	"realworld-authentication/model/enum"
+	"strings"
+	"unicode"
+)
+
+const (
+	PASSWORD_MIN_USER_INFO_LENGTH = 3
+)
+
+// PasswordRuleFailure is one rule of the password policy the password does not meet
+type PasswordRuleFailure struct {
+	Rule    enum.PasswordRuleValue
+	Message string
+}
+
+// ValidatePasswordPolicy checks the password against the configured policy and returns every failed rule,
+// userInputs are the username and email of the user, they must not appear in the password
+func ValidatePasswordPolicy(password string, userInputs ...string) []*PasswordRuleFailure {
+	policy := env.AppConfig.PasswordPolicy
+	var failures []*PasswordRuleFailure
+	fail := func(rule enum.PasswordRuleValue, format string, args ...interface{}) {
+		failures = append(failures, &PasswordRuleFailure{
+			Rule:    rule,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	length := len([]rune(password))
+	if length < policy.MinLength {
+		fail(enum.PasswordRule.MinLength, "password must be at least %d characters", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		// the strength is not estimated for very long input, it would be too slow
+		fail(enum.PasswordRule.MaxLength, "password must be at most %d characters", policy.MaxLength)
+		return failures
+	}
+
+	var lowercase, uppercase, digits, symbols int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lowercase++
+		case unicode.IsUpper(r):
+			uppercase++
+		case unicode.IsDigit(r):
+			digits++
+		case !unicode.IsLetter(r):
+			symbols++
+		}
+	}
+	if lowercase < policy.MinLowercase {
+		fail(enum.PasswordRule.Lowercase, "password must contain at least %d lowercase letters", policy.MinLowercase)
+	}
+	if uppercase < policy.MinUppercase {
+		fail(enum.PasswordRule.Uppercase, "password must contain at least %d uppercase letters", policy.MinUppercase)
+	}
+	if digits < policy.MinDigits {
+		fail(enum.PasswordRule.Digit, "password must contain at least %d numbers", policy.MinDigits)
+	}
+	if symbols < policy.MinSymbols {
+		fail(enum.PasswordRule.Symbol, "password must contain at least %d special characters", policy.MinSymbols)
+	}
+
+	userInfo := getPasswordUserInfo(userInputs)
+	if policy.DisallowUserInfo && containsUserInfo(password, userInfo) {
+		fail(enum.PasswordRule.ContainsUserInfo, "password must not contain your username or email")
+	}
+
+	if policy.MinStrengthScore > 0 {
+		if score := EstimatePasswordStrength(password, userInfo...); score < policy.MinStrengthScore {
+			fail(enum.PasswordRule.Strength, "password is too easy to guess, its strength is %d of required %d", score, policy.MinStrengthScore)
+		}
+	}
+
+	return failures
+}
+
+// getPasswordUserInfo adds the local part of emails, it is often reused as the username
+func getPasswordUserInfo(userInputs []string) []string {
+	var userInfo []string
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input == "" {
+			continue
+		}
+		userInfo = append(userInfo, input)
+		if at := strings.LastIndex(input, "@"); at > 0 {
+			userInfo = append(userInfo, input[:at])
+		}
+	}
+
+	return userInfo
+}
+
+func containsUserInfo(password string, userInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range userInfo {
+		if len([]rune(info)) >= PASSWORD_MIN_USER_INFO_LENGTH && strings.Contains(password, info) {
+			return true
+		}
+	}
+
+	return false
+}
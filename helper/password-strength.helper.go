@@ -0,0 +1,279 @@
+package helper
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	PASSWORD_BRUTEFORCE_CARDINALITY = 10
+	PASSWORD_MIN_MATCH_GUESSES      = 10
+	PASSWORD_MIN_DICTIONARY_LENGTH  = 3
+)
+
+//go:embed common-passwords.txt
+var commonPasswordList string
+
+var (
+	// rank of the common passwords, the most used password is guessed first
+	commonPasswordRanks = newPasswordRanks(strings.Fields(commonPasswordList))
+
+	keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "azertyuiop", "qwertzuiop"}
+
+	leetSubstitutions = map[rune]rune{
+		'4': 'a', '@': 'a', '3': 'e', '1': 'i', '!': 'i', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't',
+	}
+
+	// guesses needed for the score from 1 to 4, lower than the first one is 0
+	passwordScoreGuesses = []float64{1e3, 1e6, 1e8, 1e10}
+)
+
+type passwordMatch struct {
+	start   int
+	end     int
+	guesses float64
+}
+
+// EstimatePasswordStrength scores the password from 0 to 4 like zxcvbn, it finds the cheapest way to guess the
+// password from common passwords, user inputs, sequences, repeats, keyboard patterns and years, unmatched
+// characters are brute forced
+func EstimatePasswordStrength(password string, userInputs ...string) int {
+	guesses := estimatePasswordGuesses([]rune(password), newPasswordRanks(userInputs))
+	score := 0
+	for _, threshold := range passwordScoreGuesses {
+		if guesses < threshold {
+			break
+		}
+		score++
+	}
+
+	return score
+}
+
+func estimatePasswordGuesses(password []rune, userInputRanks map[string]int) float64 {
+	matches := findPasswordMatches(password, userInputRanks)
+
+	// best[k] is the fewest guesses of the first k characters
+	best := make([]float64, len(password)+1)
+	best[0] = 1
+	for k := 1; k <= len(password); k++ {
+		best[k] = best[k-1] * PASSWORD_BRUTEFORCE_CARDINALITY
+		for _, match := range matches {
+			if match.end == k {
+				best[k] = math.Min(best[k], best[match.start]*match.guesses)
+			}
+		}
+	}
+
+	return best[len(password)]
+}
+
+func findPasswordMatches(password []rune, userInputRanks map[string]int) []*passwordMatch {
+	var matches []*passwordMatch
+	lower := []rune(strings.ToLower(string(password)))
+
+	for i := 0; i < len(password); i++ {
+		for j := i + PASSWORD_MIN_DICTIONARY_LENGTH; j <= len(password); j++ {
+			if guesses, ok := dictionaryGuesses(password[i:j], lower[i:j], userInputRanks); ok {
+				matches = append(matches, &passwordMatch{start: i, end: j, guesses: guesses})
+			}
+			if guesses, ok := sequenceGuesses(lower[i:j]); ok {
+				matches = append(matches, &passwordMatch{start: i, end: j, guesses: guesses})
+			}
+			if guesses, ok := repeatGuesses(lower[i:j]); ok {
+				matches = append(matches, &passwordMatch{start: i, end: j, guesses: guesses})
+			}
+			if guesses, ok := keyboardGuesses(lower[i:j]); ok {
+				matches = append(matches, &passwordMatch{start: i, end: j, guesses: guesses})
+			}
+			if guesses, ok := yearGuesses(lower[i:j]); ok {
+				matches = append(matches, &passwordMatch{start: i, end: j, guesses: guesses})
+			}
+		}
+	}
+
+	for _, match := range matches {
+		match.guesses = math.Max(match.guesses, PASSWORD_MIN_MATCH_GUESSES)
+	}
+
+	return matches
+}
+
+// dictionaryGuesses looks the token up as written, reversed and with leet substitutions undone
+func dictionaryGuesses(token, lower []rune, userInputRanks map[string]int) (float64, bool) {
+	word := string(lower)
+	unleet := []rune(word)
+	substituted := false
+	for i, r := range unleet {
+		if sub, ok := leetSubstitutions[r]; ok {
+			unleet[i] = sub
+			substituted = true
+		}
+	}
+
+	candidates := []struct {
+		word       string
+		multiplier float64
+	}{
+		{word, 1},
+		{reverseString(word), 2},
+	}
+	if substituted {
+		candidates = append(candidates, struct {
+			word       string
+			multiplier float64
+		}{string(unleet), 2})
+	}
+
+	found := false
+	guesses := math.Inf(1)
+	for _, candidate := range candidates {
+		for _, ranks := range []map[string]int{userInputRanks, commonPasswordRanks} {
+			if rank, ok := ranks[candidate.word]; ok {
+				guesses = math.Min(guesses, float64(rank)*candidate.multiplier)
+				found = true
+			}
+		}
+	}
+	if !found {
+		return 0, false
+	}
+
+	return guesses * uppercaseVariations(token), true
+}
+
+// uppercaseVariations counts the capitalizations tried for a word, the first or every letter upper is common
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0 || (upper == 1 && unicode.IsUpper(token[0])):
+		return 2
+	default:
+		variations := 0.0
+		for i := 1; i <= int(math.Min(float64(upper), float64(lower))); i++ {
+			variations += binomial(upper+lower, i)
+		}
+		return variations
+	}
+}
+
+// sequenceGuesses matches runs like abc, 9876 or xyz where each character moves by the same step of 1
+func sequenceGuesses(token []rune) (float64, bool) {
+	delta := token[1] - token[0]
+	if delta != 1 && delta != -1 {
+		return 0, false
+	}
+	for i := 2; i < len(token); i++ {
+		if token[i]-token[i-1] != delta {
+			return 0, false
+		}
+	}
+
+	base := 26.0
+	switch {
+	case strings.ContainsRune("az019", token[0]):
+		base = 4
+	case unicode.IsDigit(token[0]):
+		base = 10
+	}
+	if delta < 0 {
+		base *= 2
+	}
+
+	return base * float64(len(token)), true
+}
+
+func repeatGuesses(token []rune) (float64, bool) {
+	for _, r := range token[1:] {
+		if r != token[0] {
+			return 0, false
+		}
+	}
+
+	return characterCardinality(token[0]) * float64(len(token)), true
+}
+
+// keyboardGuesses matches straight runs on a keyboard row in either direction
+func keyboardGuesses(token []rune) (float64, bool) {
+	if len(token) < 4 {
+		return 0, false
+	}
+
+	word := string(token)
+	for _, row := range keyboardRows {
+		if strings.Contains(row, word) || strings.Contains(row, reverseString(word)) {
+			return float64(len(row)) * float64(len(token)) * 2, true
+		}
+	}
+
+	return 0, false
+}
+
+func yearGuesses(token []rune) (float64, bool) {
+	if len(token) != 4 || (string(token[:2]) != "19" && string(token[:2]) != "20") {
+		return 0, false
+	}
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return 0, false
+		}
+	}
+
+	return 200, true
+}
+
+func characterCardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLetter(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+func newPasswordRanks(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len([]rune(word)) < PASSWORD_MIN_DICTIONARY_LENGTH {
+			continue
+		}
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+
+	return ranks
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
+
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+
+	return result
+}
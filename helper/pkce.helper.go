@@ -0,0 +1,36 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const (
+	PKCE_METHOD_S256    = "S256"
+	PKCE_VERIFIER_BYTES = 32
+)
+
+// GeneratePKCEVerifier returns a 43 characters code_verifier (RFC 7636 section 4.1)
+func GeneratePKCEVerifier() (string, error) {
+	b, err := generateRandomBytes(PKCE_VERIFIER_BYTES)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func ComputePKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCEChallenge only accepts the S256 method, "plain" would let an intercepted challenge redeem the code
+func VerifyPKCEChallenge(verifier, challenge, method string) bool {
+	if method != PKCE_METHOD_S256 || len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(ComputePKCEChallenge(verifier)), []byte(challenge)) == 1
+}
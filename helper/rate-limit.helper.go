@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimitResult is the state of a token bucket after a request took a token from it
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// NewRateLimitResult derives the result from the tokens left in a bucket of limit tokens refilled over period
+func NewRateLimitResult(allowed bool, limit int64, period time.Duration, tokens float64) *RateLimitResult {
+	refillTime := float64(period) / float64(limit)
+	result := &RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int64(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit) - tokens) * refillTime),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * refillTime)
+	}
+
+	return result
+}
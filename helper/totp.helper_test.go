@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 appendix B test vectors, "12345678901234567890" in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := GenerateTOTPCode(rfc6238Secret, GetTOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode(%d): %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("GenerateTOTPCode(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := GetTOTPStep(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		skew   int64
+		valid  bool
+	}{
+		{"current step", 0, 1, true},
+		{"previous step inside window", -1, 1, true},
+		{"next step inside window", 1, 1, true},
+		{"two steps behind", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+		{"previous step without skew", -1, 0, false},
+		{"next step without skew", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GenerateTOTPCode(rfc6238Secret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			step, ok := ValidateTOTPCode(rfc6238Secret, code, now, tt.skew)
+			if ok != tt.valid {
+				t.Fatalf("ValidateTOTPCode() ok = %v, want %v", ok, tt.valid)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("ValidateTOTPCode() step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPCodeRejectsMalformedCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	for _, code := range []string{"", "08180", "0818040", "abcdef"} {
+		if _, ok := ValidateTOTPCode(rfc6238Secret, code, now, 1); ok {
+			t.Errorf("ValidateTOTPCode(%q) accepted a malformed code", code)
+		}
+	}
+
+	if _, ok := ValidateTOTPCode("not base32!", "081804", now, 1); ok {
+		t.Error("ValidateTOTPCode() accepted a code for an invalid secret")
+	}
+}
@@ -0,0 +1,411 @@
+package helper
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"realworld-authentication/config/env"
+	"strings"
+)
+
+const (
+	WEBAUTHN_CHALLENGE_BYTES    = 32
+	WEBAUTHN_CEREMONY_CREATE    = "webauthn.create"
+	WEBAUTHN_CEREMONY_GET       = "webauthn.get"
+	WEBAUTHN_ATTESTATION_NONE   = "none"
+	WEBAUTHN_ATTESTATION_PACKED = "packed"
+
+	WEBAUTHN_FLAG_USER_PRESENT   = 0x01
+	WEBAUTHN_FLAG_USER_VERIFIED  = 0x04
+	WEBAUTHN_FLAG_ATTESTED_DATA  = 0x40
+	WEBAUTHN_FLAG_EXTENSION_DATA = 0x80
+
+	COSE_ALG_ES256 int64 = -7
+	COSE_ALG_EDDSA int64 = -8
+	COSE_ALG_RS256 int64 = -257
+)
+
+// WebAuthnAlgorithms lists the COSE algorithms offered to authenticators, in order of preference
+var WebAuthnAlgorithms = []int64{COSE_ALG_ES256, COSE_ALG_EDDSA, COSE_ALG_RS256}
+
+type WebAuthnClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type WebAuthnAuthenticatorData struct {
+	RPIDHash            []byte
+	Flags               byte
+	SignCount           uint32
+	AAGUID              []byte
+	CredentialID        []byte
+	CredentialPublicKey []byte
+}
+
+func (d *WebAuthnAuthenticatorData) UserVerified() bool {
+	return d.Flags&WEBAUTHN_FLAG_USER_VERIFIED != 0
+}
+
+// WebAuthnCredential is the credential created by a verified registration ceremony
+type WebAuthnCredential struct {
+	CredentialID []byte
+	PublicKey    []byte
+	Algorithm    int64
+	SignCount    uint32
+	AAGUID       []byte
+}
+
+func GenerateWebAuthnChallenge() (string, error) {
+	challenge, err := generateRandomBytes(WEBAUTHN_CHALLENGE_BYTES)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(challenge), nil
+}
+
+// DecodeBase64URL accepts base64url with or without padding, browsers and libraries differ on it
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// VerifyWebAuthnRegistration verifies the response of navigator.credentials.create, only the "none" and
+// "packed" attestation formats are accepted and attestation certificates are not chained to a root
+func VerifyWebAuthnRegistration(attestationObject, clientDataJSON []byte, challenge string, requireUserVerification bool) (*WebAuthnCredential, error) {
+	if err := verifyWebAuthnClientData(clientDataJSON, WEBAUTHN_CEREMONY_CREATE, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, rest, err := DecodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestation object: %w", err)
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, errors.New("attestation object is malformed")
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if statement == nil || rawAuthData == nil {
+		return nil, errors.New("attestation object is malformed")
+	}
+
+	authData, err := ParseWebAuthnAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyWebAuthnAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+
+	if authData.CredentialID == nil {
+		return nil, errors.New("authenticator data has no attested credential")
+	}
+
+	publicKey, algorithm, err := ParseCOSEKey(authData.CredentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+
+	switch format {
+	case WEBAUTHN_ATTESTATION_NONE:
+		if len(statement) != 0 {
+			return nil, errors.New("none attestation must have an empty statement")
+		}
+	case WEBAUTHN_ATTESTATION_PACKED:
+		statementAlg, _ := statement["alg"].(int64)
+		signature, _ := statement["sig"].([]byte)
+		if signature == nil {
+			return nil, errors.New("packed attestation has no signature")
+		}
+
+		// full attestation is signed by the certificate key, self attestation by the credential itself
+		if certificates, ok := statement["x5c"].([]interface{}); ok && len(certificates) > 0 {
+			der, _ := certificates[0].([]byte)
+			certificate, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("parse attestation certificate: %w", err)
+			}
+			err = verifyWebAuthnSignature(certificate.PublicKey, statementAlg, signedData, signature)
+			if err != nil {
+				return nil, fmt.Errorf("verify attestation: %w", err)
+			}
+		} else {
+			if statementAlg != algorithm {
+				return nil, errors.New("self attestation algorithm does not match the credential")
+			}
+			err = verifyWebAuthnSignature(publicKey, algorithm, signedData, signature)
+			if err != nil {
+				return nil, fmt.Errorf("verify attestation: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported attestation format: %s", format)
+	}
+
+	return &WebAuthnCredential{
+		CredentialID: authData.CredentialID,
+		PublicKey:    authData.CredentialPublicKey,
+		Algorithm:    algorithm,
+		SignCount:    authData.SignCount,
+		AAGUID:       authData.AAGUID,
+	}, nil
+}
+
+// VerifyWebAuthnAssertion verifies the response of navigator.credentials.get against the stored COSE public key,
+// the caller still has to compare the returned sign count with the stored one
+func VerifyWebAuthnAssertion(publicKey, rawAuthData, clientDataJSON, signature []byte, challenge string, requireUserVerification bool) (*WebAuthnAuthenticatorData, error) {
+	if err := verifyWebAuthnClientData(clientDataJSON, WEBAUTHN_CEREMONY_GET, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseWebAuthnAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyWebAuthnAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+
+	key, algorithm, err := ParseCOSEKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifyWebAuthnSignature(key, algorithm, signedData, signature); err != nil {
+		return nil, fmt.Errorf("verify assertion: %w", err)
+	}
+
+	return authData, nil
+}
+
+// ValidateWebAuthnSignCount rejects a counter that did not grow, which means the authenticator may be cloned,
+// authenticators which do not implement the counter always report zero
+func ValidateWebAuthnSignCount(storedCount, receivedCount uint32) error {
+	if storedCount == 0 && receivedCount == 0 {
+		return nil
+	}
+
+	if receivedCount <= storedCount {
+		return errors.New("sign count did not increase, the authenticator may be cloned")
+	}
+
+	return nil
+}
+
+func ParseWebAuthnAuthenticatorData(data []byte) (*WebAuthnAuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+
+	authData := &WebAuthnAuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if authData.Flags&WEBAUTHN_FLAG_ATTESTED_DATA != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data is too short")
+		}
+
+		authData.AAGUID = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLength {
+			return nil, errors.New("attested credential data is too short")
+		}
+
+		authData.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, afterKey, err := DecodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("decode credential public key: %w", err)
+		}
+
+		authData.CredentialPublicKey = rest[:len(rest)-len(afterKey)]
+		rest = afterKey
+	}
+
+	if authData.Flags&WEBAUTHN_FLAG_EXTENSION_DATA != 0 {
+		_, afterExtensions, err := DecodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("decode extensions: %w", err)
+		}
+		rest = afterExtensions
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("authenticator data has trailing bytes")
+	}
+
+	return authData, nil
+}
+
+// ParseCOSEKey reads an EC2 P-256, OKP Ed25519 or RSA public key
+func ParseCOSEKey(data []byte) (crypto.PublicKey, int64, error) {
+	decoded, rest, err := DecodeCBOR(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("decode cose key: %w", err)
+	}
+
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, 0, errors.New("cose key is malformed")
+	}
+
+	keyType, _ := key[int64(1)].(int64)
+	algorithm, _ := key[int64(3)].(int64)
+
+	switch {
+	case keyType == 2 && algorithm == COSE_ALG_ES256:
+		curve, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if curve != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("cose key is not a P-256 key")
+		}
+
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, 0, errors.New("cose key point is not on curve")
+		}
+		return publicKey, algorithm, nil
+	case keyType == 1 && algorithm == COSE_ALG_EDDSA:
+		curve, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if curve != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("cose key is not an Ed25519 key")
+		}
+		return ed25519.PublicKey(x), algorithm, nil
+	case keyType == 3 && algorithm == COSE_ALG_RS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("cose key is not a valid RSA key")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, algorithm, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported cose key type %d with algorithm %d", keyType, algorithm)
+	}
+}
+
+// ParseWebAuthnClientData reads the client data without verifying it, it is used to look up the challenge
+func ParseWebAuthnClientData(clientDataJSON []byte) (*WebAuthnClientData, error) {
+	var clientData WebAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return nil, fmt.Errorf("decode client data: %w", err)
+	}
+
+	clientData.Challenge = strings.TrimRight(clientData.Challenge, "=")
+	return &clientData, nil
+}
+
+func verifyWebAuthnClientData(clientDataJSON []byte, ceremonyType, challenge string) error {
+	clientData, err := ParseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if clientData.Type != ceremonyType {
+		return errors.New("client data has an unexpected type")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return errors.New("client data challenge does not match")
+	}
+
+	if clientData.CrossOrigin {
+		return errors.New("cross origin ceremonies are not allowed")
+	}
+
+	for _, origin := range env.AppConfig.WebAuthnOrigins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("origin %s is not allowed", clientData.Origin)
+}
+
+func verifyWebAuthnAuthenticatorData(authData *WebAuthnAuthenticatorData, requireUserVerification bool) error {
+	rpIDHash := sha256.Sum256([]byte(env.AppConfig.WebAuthnRPID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return errors.New("authenticator data belongs to another relying party")
+	}
+
+	if authData.Flags&WEBAUTHN_FLAG_USER_PRESENT == 0 {
+		return errors.New("user presence is required")
+	}
+
+	if requireUserVerification && !authData.UserVerified() {
+		return errors.New("user verification is required")
+	}
+
+	return nil
+}
+
+func verifyWebAuthnSignature(publicKey crypto.PublicKey, algorithm int64, data, signature []byte) error {
+	switch algorithm {
+	case COSE_ALG_ES256:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match ES256")
+		}
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errors.New("signature is invalid")
+		}
+		return nil
+	case COSE_ALG_EDDSA:
+		key, ok := publicKey.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("key does not match EdDSA")
+		}
+		if !ed25519.Verify(key, data, signature) {
+			return errors.New("signature is invalid")
+		}
+		return nil
+	case COSE_ALG_RS256:
+		key, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match RS256")
+		}
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	default:
+		return fmt.Errorf("unsupported algorithm %d", algorithm)
+	}
+}
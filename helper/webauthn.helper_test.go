@@ -0,0 +1,418 @@
+package helper
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"realworld-authentication/config/env"
+	"testing"
+)
+
+const (
+	testWebAuthnRPID   = "auth.example.com"
+	testWebAuthnOrigin = "https://auth.example.com"
+)
+
+// softwareAuthenticator signs WebAuthn ceremonies the way a security key does, with an ES256 or Ed25519 key
+type softwareAuthenticator struct {
+	credentialID []byte
+	ecKey        *ecdsa.PrivateKey
+	edKey        ed25519.PrivateKey
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(t testing.TB, algorithm int64) *softwareAuthenticator {
+	t.Helper()
+
+	authenticator := &softwareAuthenticator{credentialID: make([]byte, 32)}
+	if _, err := rand.Read(authenticator.credentialID); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	switch algorithm {
+	case COSE_ALG_ES256:
+		authenticator.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case COSE_ALG_EDDSA:
+		_, authenticator.edKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported algorithm %d", algorithm)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return authenticator
+}
+
+func (a *softwareAuthenticator) algorithm() int64 {
+	if a.ecKey != nil {
+		return COSE_ALG_ES256
+	}
+
+	return COSE_ALG_EDDSA
+}
+
+func (a *softwareAuthenticator) coseKey() []byte {
+	if a.ecKey != nil {
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		a.ecKey.X.FillBytes(x)
+		a.ecKey.Y.FillBytes(y)
+		return encodeCBOR(cborMap{{1, 2}, {3, COSE_ALG_ES256}, {-1, 1}, {-2, x}, {-3, y}})
+	}
+
+	return encodeCBOR(cborMap{{1, 1}, {3, COSE_ALG_EDDSA}, {-1, 6}, {-2, []byte(a.edKey.Public().(ed25519.PublicKey))}})
+}
+
+func (a *softwareAuthenticator) sign(t testing.TB, data []byte) []byte {
+	t.Helper()
+
+	if a.ecKey == nil {
+		return ed25519.Sign(a.edKey, data)
+	}
+
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signature
+}
+
+func (a *softwareAuthenticator) authenticatorData(rpID string, flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], a.signCount)
+	if !attested {
+		return data
+	}
+
+	data = append(data, make([]byte, 18)...)
+	binary.BigEndian.PutUint16(data[len(data)-2:], uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	return append(data, a.coseKey()...)
+}
+
+// create answers navigator.credentials.create with self attestation, or none when packed is false
+func (a *softwareAuthenticator) create(t testing.TB, challenge string, packed bool) ([]byte, []byte) {
+	t.Helper()
+
+	clientDataJSON := newWebAuthnClientDataJSON(t, WEBAUTHN_CEREMONY_CREATE, challenge, testWebAuthnOrigin)
+	authData := a.authenticatorData(testWebAuthnRPID, WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_USER_VERIFIED|WEBAUTHN_FLAG_ATTESTED_DATA, true)
+
+	format := WEBAUTHN_ATTESTATION_NONE
+	statement := cborMap{}
+	if packed {
+		format = WEBAUTHN_ATTESTATION_PACKED
+		statement = cborMap{{"alg", a.algorithm()}, {"sig", a.sign(t, signedWebAuthnData(authData, clientDataJSON))}}
+	}
+
+	return encodeCBOR(cborMap{{"fmt", format}, {"attStmt", statement}, {"authData", authData}}), clientDataJSON
+}
+
+// get answers navigator.credentials.get, every assertion moves the sign counter forward
+func (a *softwareAuthenticator) get(t testing.TB, challenge string, flags byte) ([]byte, []byte, []byte) {
+	t.Helper()
+
+	a.signCount++
+	clientDataJSON := newWebAuthnClientDataJSON(t, WEBAUTHN_CEREMONY_GET, challenge, testWebAuthnOrigin)
+	authData := a.authenticatorData(testWebAuthnRPID, flags, false)
+	return authData, clientDataJSON, a.sign(t, signedWebAuthnData(authData, clientDataJSON))
+}
+
+func newWebAuthnClientDataJSON(t testing.TB, ceremonyType, challenge, origin string) []byte {
+	t.Helper()
+
+	clientDataJSON, err := json.Marshal(&WebAuthnClientData{
+		Type:      ceremonyType,
+		Challenge: challenge,
+		Origin:    origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return clientDataJSON
+}
+
+func signedWebAuthnData(authData, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	return append(append([]byte{}, authData...), clientDataHash[:]...)
+}
+
+func loadWebAuthnTestConfig(t testing.TB) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	config := "webauthn_rp_id: " + testWebAuthnRPID + "\nwebauthn_origins:\n  - " + testWebAuthnOrigin + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.LoadConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := GenerateWebAuthnChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return challenge
+}
+
+func TestVerifyWebAuthnRegistration(t *testing.T) {
+	challenge := loadWebAuthnTestConfig(t)
+
+	tests := []struct {
+		name      string
+		algorithm int64
+		packed    bool
+	}{
+		{"ES256 none attestation", COSE_ALG_ES256, false},
+		{"ES256 packed self attestation", COSE_ALG_ES256, true},
+		{"EdDSA none attestation", COSE_ALG_EDDSA, false},
+		{"EdDSA packed self attestation", COSE_ALG_EDDSA, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftwareAuthenticator(t, tt.algorithm)
+			attestationObject, clientDataJSON := authenticator.create(t, challenge, tt.packed)
+
+			credential, err := VerifyWebAuthnRegistration(attestationObject, clientDataJSON, challenge, true)
+			if err != nil {
+				t.Fatalf("VerifyWebAuthnRegistration(): %v", err)
+			}
+			if string(credential.CredentialID) != string(authenticator.credentialID) || credential.Algorithm != tt.algorithm {
+				t.Fatalf("VerifyWebAuthnRegistration() = %+v", credential)
+			}
+			if string(credential.PublicKey) != string(authenticator.coseKey()) {
+				t.Fatal("VerifyWebAuthnRegistration() did not keep the cose public key")
+			}
+		})
+	}
+}
+
+func TestVerifyWebAuthnRegistrationRejectsInvalidResponse(t *testing.T) {
+	challenge := loadWebAuthnTestConfig(t)
+	authenticator := newSoftwareAuthenticator(t, COSE_ALG_ES256)
+	attestationObject, clientDataJSON := authenticator.create(t, challenge, true)
+
+	otherChallenge, err := GenerateWebAuthnChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedObject := append([]byte{}, attestationObject...)
+	tamperedObject[len(tamperedObject)-1] ^= 0xff
+
+	tests := []struct {
+		name              string
+		attestationObject []byte
+		clientDataJSON    []byte
+		challenge         string
+	}{
+		{"other challenge", attestationObject, clientDataJSON, otherChallenge},
+		{"assertion client data", attestationObject, newWebAuthnClientDataJSON(t, WEBAUTHN_CEREMONY_GET, challenge, testWebAuthnOrigin), challenge},
+		{"other origin", attestationObject, newWebAuthnClientDataJSON(t, WEBAUTHN_CEREMONY_CREATE, challenge, "https://evil.example.com"), challenge},
+		{"tampered authenticator data", tamperedObject, clientDataJSON, challenge},
+		{"empty attestation object", nil, clientDataJSON, challenge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyWebAuthnRegistration(tt.attestationObject, tt.clientDataJSON, tt.challenge, false); err == nil {
+				t.Fatal("VerifyWebAuthnRegistration() accepted an invalid response")
+			}
+		})
+	}
+}
+
+func TestVerifyWebAuthnRegistrationRejectsOtherRelyingParty(t *testing.T) {
+	challenge := loadWebAuthnTestConfig(t)
+	authenticator := newSoftwareAuthenticator(t, COSE_ALG_ES256)
+
+	clientDataJSON := newWebAuthnClientDataJSON(t, WEBAUTHN_CEREMONY_CREATE, challenge, testWebAuthnOrigin)
+	authData := authenticator.authenticatorData("evil.example.com", WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_ATTESTED_DATA, true)
+	attestationObject := encodeCBOR(cborMap{{"fmt", WEBAUTHN_ATTESTATION_NONE}, {"attStmt", cborMap{}}, {"authData", authData}})
+
+	if _, err := VerifyWebAuthnRegistration(attestationObject, clientDataJSON, challenge, false); err == nil {
+		t.Fatal("VerifyWebAuthnRegistration() accepted authenticator data of another relying party")
+	}
+}
+
+func TestVerifyWebAuthnAssertion(t *testing.T) {
+	challenge := loadWebAuthnTestConfig(t)
+
+	for _, algorithm := range []int64{COSE_ALG_ES256, COSE_ALG_EDDSA} {
+		authenticator := newSoftwareAuthenticator(t, algorithm)
+
+		authData, clientDataJSON, signature := authenticator.get(t, challenge, WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_USER_VERIFIED)
+		parsed, err := VerifyWebAuthnAssertion(authenticator.coseKey(), authData, clientDataJSON, signature, challenge, true)
+		if err != nil {
+			t.Fatalf("VerifyWebAuthnAssertion(%d): %v", algorithm, err)
+		}
+		if parsed.SignCount != authenticator.signCount || !parsed.UserVerified() {
+			t.Fatalf("VerifyWebAuthnAssertion(%d) = %+v", algorithm, parsed)
+		}
+	}
+}
+
+func TestVerifyWebAuthnAssertionRejectsInvalidResponse(t *testing.T) {
+	challenge := loadWebAuthnTestConfig(t)
+	authenticator := newSoftwareAuthenticator(t, COSE_ALG_ES256)
+	otherAuthenticator := newSoftwareAuthenticator(t, COSE_ALG_ES256)
+
+	authData, clientDataJSON, signature := authenticator.get(t, challenge, WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_USER_VERIFIED)
+	tamperedSignature := append([]byte{}, signature...)
+	tamperedSignature[len(tamperedSignature)-1] ^= 0xff
+	presentOnlyData, presentOnlyClientData, presentOnlySignature := authenticator.get(t, challenge, WEBAUTHN_FLAG_USER_PRESENT)
+	absentData, absentClientData, absentSignature := authenticator.get(t, challenge, 0)
+
+	tests := []struct {
+		name                    string
+		publicKey               []byte
+		authData                []byte
+		clientDataJSON          []byte
+		signature               []byte
+		requireUserVerification bool
+	}{
+		{"tampered signature", authenticator.coseKey(), authData, clientDataJSON, tamperedSignature, false},
+		{"other credential key", otherAuthenticator.coseKey(), authData, clientDataJSON, signature, false},
+		{"registration client data", authenticator.coseKey(), authData, newWebAuthnClientDataJSON(t, WEBAUTHN_CEREMONY_CREATE, challenge, testWebAuthnOrigin), signature, false},
+		{"user verification required", authenticator.coseKey(), presentOnlyData, presentOnlyClientData, presentOnlySignature, true},
+		{"user not present", authenticator.coseKey(), absentData, absentClientData, absentSignature, false},
+		{"malformed public key", []byte{0xa1}, authData, clientDataJSON, signature, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyWebAuthnAssertion(tt.publicKey, tt.authData, tt.clientDataJSON, tt.signature, challenge, tt.requireUserVerification); err == nil {
+				t.Fatal("VerifyWebAuthnAssertion() accepted an invalid response")
+			}
+		})
+	}
+}
+
+func TestValidateWebAuthnSignCount(t *testing.T) {
+	tests := []struct {
+		stored   uint32
+		received uint32
+		valid    bool
+	}{
+		{0, 0, true},
+		{0, 1, true},
+		{5, 6, true},
+		{5, 5, false},
+		{5, 4, false},
+		{5, 0, false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateWebAuthnSignCount(tt.stored, tt.received); (err == nil) != tt.valid {
+			t.Errorf("ValidateWebAuthnSignCount(%d, %d) err = %v", tt.stored, tt.received, err)
+		}
+	}
+}
+
+// TestWebAuthnParsersRejectTruncatedInput cuts a valid response at every length, no prefix may panic or verify
+func TestWebAuthnParsersRejectTruncatedInput(t *testing.T) {
+	challenge := loadWebAuthnTestConfig(t)
+	authenticator := newSoftwareAuthenticator(t, COSE_ALG_ES256)
+	attestationObject, clientDataJSON := authenticator.create(t, challenge, true)
+	authData := authenticator.authenticatorData(testWebAuthnRPID, WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_ATTESTED_DATA, true)
+	coseKey := authenticator.coseKey()
+
+	for i := 0; i < len(attestationObject); i++ {
+		if _, err := VerifyWebAuthnRegistration(attestationObject[:i], clientDataJSON, challenge, false); err == nil {
+			t.Fatalf("VerifyWebAuthnRegistration() accepted %d of %d bytes", i, len(attestationObject))
+		}
+	}
+
+	for i := 0; i < len(authData); i++ {
+		if _, err := ParseWebAuthnAuthenticatorData(authData[:i]); err == nil && i < 37 {
+			t.Fatalf("ParseWebAuthnAuthenticatorData() accepted %d bytes", i)
+		}
+	}
+
+	for i := 0; i < len(coseKey); i++ {
+		if _, _, err := ParseCOSEKey(coseKey[:i]); err == nil {
+			t.Fatalf("ParseCOSEKey() accepted %d of %d bytes", i, len(coseKey))
+		}
+	}
+}
+
+func TestParseCOSEKeyRejectsInvalidKey(t *testing.T) {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	x[31] = 1
+	y[31] = 1
+
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{"not a map", encodeCBOR([]interface{}{1, 2})},
+		{"trailing bytes", append(newSoftwareAuthenticator(t, COSE_ALG_ES256).coseKey(), 0x00)},
+		{"point not on curve", encodeCBOR(cborMap{{1, 2}, {3, COSE_ALG_ES256}, {-1, 1}, {-2, x}, {-3, y}})},
+		{"short coordinates", encodeCBOR(cborMap{{1, 2}, {3, COSE_ALG_ES256}, {-1, 1}, {-2, x[1:]}, {-3, y}})},
+		{"other curve", encodeCBOR(cborMap{{1, 2}, {3, COSE_ALG_ES256}, {-1, 2}, {-2, x}, {-3, y}})},
+		{"short Ed25519 key", encodeCBOR(cborMap{{1, 1}, {3, COSE_ALG_EDDSA}, {-1, 6}, {-2, x[1:]}})},
+		{"short RSA modulus", encodeCBOR(cborMap{{1, 3}, {3, COSE_ALG_RS256}, {-1, make([]byte, 128)}, {-2, []byte{1, 0, 1}}})},
+		{"wrong value types", encodeCBOR(cborMap{{1, "EC2"}, {3, "ES256"}, {-1, 1}, {-2, x}, {-3, y}})},
+		{"unsupported algorithm", encodeCBOR(cborMap{{1, 2}, {3, -35}, {-1, 2}, {-2, x}, {-3, y}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseCOSEKey(tt.key); err == nil {
+				t.Fatal("ParseCOSEKey() accepted an invalid key")
+			}
+		})
+	}
+}
+
+func TestParseWebAuthnClientData(t *testing.T) {
+	challenge := base64.RawURLEncoding.EncodeToString([]byte("challenge"))
+
+	clientData, err := ParseWebAuthnClientData([]byte(`{"type":"webauthn.get","challenge":"` + challenge + `==","origin":"` + testWebAuthnOrigin + `"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clientData.Challenge != challenge {
+		t.Fatalf("ParseWebAuthnClientData() challenge = %s, want %s", clientData.Challenge, challenge)
+	}
+
+	if _, err := ParseWebAuthnClientData([]byte(`{"type":`)); err == nil {
+		t.Fatal("ParseWebAuthnClientData() accepted malformed json")
+	}
+}
+
+// FuzzParseWebAuthnAuthenticatorData feeds arbitrary authenticator data, parsing must fail cleanly instead of panicking
+func FuzzParseWebAuthnAuthenticatorData(f *testing.F) {
+	authenticator := newSoftwareAuthenticator(f, COSE_ALG_ES256)
+	f.Add(authenticator.authenticatorData(testWebAuthnRPID, WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_ATTESTED_DATA, true))
+	f.Add(authenticator.authenticatorData(testWebAuthnRPID, WEBAUTHN_FLAG_USER_PRESENT|WEBAUTHN_FLAG_EXTENSION_DATA, false))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		authData, err := ParseWebAuthnAuthenticatorData(data)
+		if err != nil {
+			return
+		}
+
+		if authData.CredentialPublicKey != nil {
+			_, _, _ = ParseCOSEKey(authData.CredentialPublicKey)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"realworld-authentication/config/db"
 	"realworld-authentication/config/env"
 	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
 	"realworld-authentication/server"
 
 	"github.com/labstack/echo/v4"
@@ -21,6 +22,9 @@ func init() {
 
 	// connect database
 	db.ConnectDB()
+	if env.AppConfig.RevokedTokenDriver == enum.StorageDriver.Redis || env.AppConfig.RateLimitDriver == enum.StorageDriver.Redis {
+		db.ConnectRedis()
+	}
 
 	// setup server app
 	app.Init(db.Client.Database(env.AppConfig.DBName))
@@ -39,11 +43,50 @@ func main() {
 
 	// auth route
 	{
-		app.Router.POST("/api/auth/signup", app.AuthController.SignUp)
-		app.Router.POST("/api/auth/login", app.AuthController.Login)
-		app.Router.POST("/api/auth/token/refresh", app.AuthController.RefreshToken)
+		app.Router.POST("/api/auth/signup", app.AuthController.SignUp, app.RateLimitMiddleware.Limit("signup"))
+		app.Router.GET("/api/auth/verify-email", app.AuthController.VerifyEmail)
+		app.Router.POST("/api/auth/verify-email/resend", app.AuthController.ResendVerificationEmail)
+		app.Router.POST("/api/auth/login", app.AuthController.Login, app.RateLimitMiddleware.Limit("login_ip"), app.RateLimitMiddleware.Limit("login_email"))
+		app.Router.POST("/api/auth/login/2fa", app.AuthController.LoginWithTwoFactor, app.RateLimitMiddleware.Limit("login_2fa"))
+		app.Router.POST("/api/auth/login/2fa/webauthn/begin", app.AuthController.BeginWebAuthnTwoFactor, app.RateLimitMiddleware.Limit("login_2fa"))
+		app.Router.POST("/api/auth/login/2fa/webauthn/finish", app.AuthController.LoginWithWebAuthnTwoFactor, app.RateLimitMiddleware.Limit("login_2fa"))
+		app.Router.POST("/api/auth/webauthn/login/begin", app.AuthController.BeginWebAuthnLogin)
+		app.Router.POST("/api/auth/webauthn/login/finish", app.AuthController.LoginWithWebAuthn)
+		app.Router.POST("/api/auth/magic-link", app.AuthController.SendMagicLink)
+		app.Router.GET("/api/auth/magic-link/verify", app.AuthController.VerifyMagicLink)
+		app.Router.POST("/api/auth/token/refresh", app.AuthController.RefreshToken, app.RateLimitMiddleware.Limit("refresh"))
+		app.Router.POST("/api/auth/password/reset", app.AuthController.ResetPasswordWithToken)
 		app.Router.POST("/api/auth/logout", app.AuthController.Logout, app.AuthMiddlware.TokenAuthMiddleware)
-		app.Router.GET("/api/sessions/oauth/google", app.AuthController.GoogleOauth, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.GET("/api/sessions/oauth/:provider/start", app.AuthController.ProviderOauthStart)
+		app.Router.GET("/api/sessions/oauth/google", app.AuthController.GoogleOauth)
+		app.Router.GET("/api/sessions/oauth/github", app.AuthController.GithubOauth)
+		app.Router.GET("/api/sessions/oauth/:provider", app.AuthController.ProviderOauth)
+	}
+
+	// signing key route
+	{
+		app.Router.GET("/.well-known/jwks.json", app.KeyController.GetJWKS)
+		app.Router.GET("/api/admin/signing-keys", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.KeyController.GetSigningKeys))
+		app.Router.POST("/api/admin/signing-keys", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.KeyController.CreateSigningKey))
+		app.Router.PUT("/api/admin/signing-keys/:keyID/promote", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.KeyController.PromoteSigningKey))
+	}
+
+	// admin user route
+	{
+		app.Router.PUT("/api/admin/users/:userID/password-change", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.AuthController.RequirePasswordChange))
+	}
+
+	// openid connect route
+	{
+		app.Router.GET("/.well-known/openid-configuration", app.OAuthController.GetOpenIDConfiguration)
+		app.Router.GET("/api/oauth/authorize", app.OAuthController.AuthorizeRedirect)
+		app.Router.POST("/api/oauth/authorize", app.OAuthController.Authorize, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/oauth/token", app.OAuthController.Token)
+		app.Router.GET("/api/oauth/userinfo", app.OAuthController.GetUserInfo, app.AuthMiddlware.OAuthTokenAuthMiddleware(enum.OAuthScope.OpenID))
+		app.Router.POST("/api/oauth/userinfo", app.OAuthController.GetUserInfo, app.AuthMiddlware.OAuthTokenAuthMiddleware(enum.OAuthScope.OpenID))
+		app.Router.GET("/api/admin/clients", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.OAuthController.GetClients))
+		app.Router.POST("/api/admin/clients", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.OAuthController.CreateClient))
+		app.Router.DELETE("/api/admin/clients/:clientID", app.AuthMiddlware.RolePermissionAuthorize(enum.UserRole.Admin, app.OAuthController.DeleteClient))
 	}
 
 	// user route
@@ -51,8 +94,24 @@ func main() {
 		app.Router.GET("/api/users/:userID/profile", app.AuthController.GetUserProfileByID)
 		app.Router.GET("/api/users/me/profile", app.AuthController.GetMyProfile, app.AuthMiddlware.TokenAuthMiddleware)
 		app.Router.PUT("/api/users/:userID/profile", app.AuthController.UpdateUserProfile, app.AuthMiddlware.TokenAuthMiddleware)
-		app.Router.PUT("/api/users/me/reset-password", app.AuthController.ResetUserPassword, app.AuthMiddlware.TokenAuthMiddleware)
-		app.Router.PUT("/api/users/forget-password", app.AuthController.ForgetPassword)
+		app.Router.PUT("/api/users/me/reset-password", app.AuthController.ResetUserPassword, app.AuthMiddlware.PasswordChangeAuthMiddleware, app.RateLimitMiddleware.Limit("reset_password"))
+		app.Router.PUT("/api/users/forget-password", app.AuthController.ForgetPassword, app.RateLimitMiddleware.Limit("forget_password_ip"), app.RateLimitMiddleware.Limit("forget_password_email"))
+		app.Router.POST("/api/users/me/2fa/setup", app.AuthController.SetupTwoFactor, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/users/me/2fa/confirm", app.AuthController.ConfirmTwoFactor, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.GET("/api/users/me/sessions", app.AuthController.GetMySessions, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.DELETE("/api/users/me/sessions/others", app.AuthController.RevokeOtherSessions, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.DELETE("/api/users/me/sessions/:sessionID", app.AuthController.RevokeSession, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.GET("/api/users/me/identities", app.AuthController.GetMyIdentities, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/users/me/identities/:provider", app.AuthController.LinkIdentity, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.DELETE("/api/users/me/identities/:provider", app.AuthController.UnlinkIdentity, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.GET("/api/users/me/api-keys", app.APIKeyController.GetMyAPIKeys, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/users/me/api-keys", app.APIKeyController.CreateAPIKey, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.DELETE("/api/users/me/api-keys/:keyID", app.APIKeyController.RevokeAPIKey, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/users/me/webauthn/register/begin", app.AuthController.BeginWebAuthnRegistration, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/users/me/webauthn/register/finish", app.AuthController.FinishWebAuthnRegistration, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.GET("/api/users/me/webauthn/credentials", app.AuthController.GetMyWebAuthnCredentials, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.DELETE("/api/users/me/webauthn/credentials/:credentialID", app.AuthController.DeleteWebAuthnCredential, app.AuthMiddlware.TokenAuthMiddleware)
+		app.Router.POST("/api/users/me/recovery-codes", app.AuthController.RegenerateRecoveryCodes, app.AuthMiddlware.TokenAuthMiddleware)
 		app.Router.POST("/api/upload", app.AuthController.UploadFile, app.AuthMiddlware.TokenAuthMiddleware)
 	}
 
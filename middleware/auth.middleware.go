@@ -4,36 +4,54 @@ import (
 	"errors"
 	"net/http"
 	"realworld-authentication/config/env"
+	"realworld-authentication/entity"
 	"realworld-authentication/helper"
 	"realworld-authentication/model/enum"
+	apikey_service "realworld-authentication/service/apikey"
 	auth_service "realworld-authentication/service/auth"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
+const HeaderAPIKey = "X-API-Key"
+
+// apiKeyRoutes are the only routes an api key may call, keyed by method and route path. Everything touching the
+// login methods, sessions, passwords, api keys or oauth consent needs the user session, so a leaked key cannot
+// take the account over
+var apiKeyRoutes = map[string]bool{
+	http.MethodGet + " /api/users/me/profile":              true,
+	http.MethodGet + " /api/users/me/sessions":             true,
+	http.MethodGet + " /api/users/me/identities":           true,
+	http.MethodGet + " /api/users/me/api-keys":             true,
+	http.MethodGet + " /api/users/me/webauthn/credentials": true,
+	http.MethodPost + " /api/upload":                       true,
+}
+
 type AuthMiddleware struct {
-	authStorage auth_service.AuthStorage
+	authStorage         auth_service.AuthStorage
+	revokedTokenStorage auth_service.RevokedTokenStorage
+	apiKeyStorage       apikey_service.APIKeyStorage
 }
 
-func NewAuthMiddleware(s auth_service.AuthStorage) *AuthMiddleware {
+func NewAuthMiddleware(s auth_service.AuthStorage, r auth_service.RevokedTokenStorage, a apikey_service.APIKeyStorage) *AuthMiddleware {
 	return &AuthMiddleware{
-		authStorage: s,
+		authStorage:         s,
+		revokedTokenStorage: r,
+		apiKeyStorage:       a,
 	}
 }
 
+// TokenAuthMiddleware accepts a Bearer access token or a personal api key in the X-API-Key header. Tokens of the
+// client credentials grant pass as well, "callerType" tells the handler whether a user or a client is calling
 func (m *AuthMiddleware) TokenAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// Get the Authorization header value
-		token, err := extractTokenFromHeaderString(c.Request().Header.Get("Authorization"))
-		if err != nil {
-			return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
-				Status:  helper.APIStatus.Unauthorized,
-				Message: err.Error(),
-			})
+		if key := c.Request().Header.Get(HeaderAPIKey); key != "" {
+			return m.apiKeyAuth(c, key, next)
 		}
 
-		claims, err := helper.ValidateToken(token, env.AppConfig.AccessTokenKey)
+		claims, err := m.validateAccessToken(c, enum.TokenUse.Access, enum.TokenUse.ClientAccess)
 		if err != nil {
 			return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
 				Status:  helper.APIStatus.Unauthorized,
@@ -41,15 +59,85 @@ func (m *AuthMiddleware) TokenAuthMiddleware(next echo.HandlerFunc) echo.Handler
 			})
 		}
 
-		c.Set("userId", claims.UserID)
+		setTokenClaims(c, claims)
 		return next(c)
 	}
 }
 
+// OAuthTokenAuthMiddleware accepts the access tokens issued to relying parties by the token endpoint, the token
+// must have been granted every given scope
+func (m *AuthMiddleware) OAuthTokenAuthMiddleware(scopes ...enum.OAuthScopeValue) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, err := m.validateAccessToken(c, enum.TokenUse.OAuthAccess)
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return c.JSON(http.StatusUnauthorized, &entity.OAuthErrorResponse{
+					Error:            "invalid_token",
+					ErrorDescription: err.Error(),
+				})
+			}
+
+			for _, scope := range scopes {
+				if !helper.HasScope(claims.Scope, scope) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="`+string(enum.OAuthErrorCode.InsufficientScope)+`", scope="`+string(scope)+`"`)
+					return c.JSON(http.StatusForbidden, &entity.OAuthErrorResponse{
+						Error:            string(enum.OAuthErrorCode.InsufficientScope),
+						ErrorDescription: "access token was not granted the " + string(scope) + " scope",
+					})
+				}
+			}
+
+			setTokenClaims(c, claims)
+			return next(c)
+		}
+	}
+}
+
+// PasswordChangeAuthMiddleware also accepts the password change token given at login when the password must be
+// changed, that token is signed with its own key so no other route accepts it
+func (m *AuthMiddleware) PasswordChangeAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(HeaderAPIKey) == "" {
+			token, err := extractTokenFromHeaderString(c.Request().Header.Get("Authorization"))
+			if err == nil {
+				if claims, err := helper.ValidateToken(token, env.AppConfig.PasswordChangeTokenKey); err == nil {
+					if !m.isPasswordChangeTokenCurrent(claims) {
+						return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+							Status:  helper.APIStatus.Unauthorized,
+							Message: "password change token is no longer valid",
+						})
+					}
+
+					c.Set("callerType", enum.CallerType.User)
+					c.Set("userId", claims.UserID)
+					return next(c)
+				}
+			}
+		}
+
+		return m.TokenAuthMiddleware(next)(c)
+	}
+}
+
+// isPasswordChangeTokenCurrent is false once the password was changed after the token was issued, the token only
+// allows the one change it was given for
+func (m *AuthMiddleware) isPasswordChangeTokenCurrent(claims *helper.TokenDetails) bool {
+	if claims.IssuedAt == nil {
+		return false
+	}
+
+	user, err := m.authStorage.GetUserByID(claims.UserID)
+	if err != nil {
+		return false
+	}
+
+	return user.PasswordChangedAt == nil || !time.Unix(*claims.IssuedAt, 0).Before(*user.PasswordChangedAt)
+}
+
 func (m *AuthMiddleware) RolePermissionAuthorize(role enum.UserRoleValue, next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// Get the Authorization header value
-		token, err := extractTokenFromHeaderString(c.Request().Header.Get("Authorization"))
+		claims, err := m.validateAccessToken(c, enum.TokenUse.Access, enum.TokenUse.ClientAccess)
 		if err != nil {
 			return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
 				Status:  helper.APIStatus.Unauthorized,
@@ -57,15 +145,14 @@ func (m *AuthMiddleware) RolePermissionAuthorize(role enum.UserRoleValue, next e
 			})
 		}
 
-		claims, err := helper.ValidateToken(token, env.AppConfig.AccessTokenKey)
-		if err != nil {
-			return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
-				Status:  helper.APIStatus.Unauthorized,
-				Message: err.Error(),
+		// get role from userId to check, client tokens have no role
+		if claims.CallerType() != enum.CallerType.User {
+			return c.JSON(http.StatusForbidden, &helper.APIResponse{
+				Status:  helper.APIStatus.Invalid,
+				Message: "Your account cannot perform this action",
 			})
 		}
 
-		// get role from userId to check
 		user, err := m.authStorage.GetUserByID(claims.UserID)
 		if err != nil || user.Role != role {
 			return c.JSON(http.StatusForbidden, &helper.APIResponse{
@@ -74,11 +161,114 @@ func (m *AuthMiddleware) RolePermissionAuthorize(role enum.UserRoleValue, next e
 			})
 		}
 
-		c.Set("userId", claims.UserID)
+		setTokenClaims(c, claims)
 		return next(c)
 	}
 }
 
+func (m *AuthMiddleware) apiKeyAuth(c echo.Context, key string, next echo.HandlerFunc) error {
+	apiKey, err := m.apiKeyStorage.UseAPIKey(helper.HashToken(key))
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: "api key is invalid or expired",
+		})
+	}
+
+	user, err := m.authStorage.GetUserByID(apiKey.UserID)
+	if err != nil || user.Status == enum.UserStatus.Inactive {
+		return c.JSON(http.StatusUnauthorized, &helper.APIResponse{
+			Status:  helper.APIStatus.Unauthorized,
+			Message: "api key owner is not active",
+		})
+	}
+
+	if !apiKeyRoutes[c.Request().Method+" "+c.Path()] {
+		return c.JSON(http.StatusForbidden, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "api key cannot call this endpoint, login to perform this action",
+		})
+	}
+
+	if !isAPIKeyMethodAllowed(apiKey.Scopes, c.Request().Method) {
+		return c.JSON(http.StatusForbidden, &helper.APIResponse{
+			Status:  helper.APIStatus.Invalid,
+			Message: "api key scope does not allow this action",
+		})
+	}
+
+	c.Set("callerType", enum.CallerType.User)
+	c.Set("userId", apiKey.UserID)
+	c.Set("apiKeyId", apiKey.KeyID)
+	c.Set("scope", strings.Join(apiKey.Scopes, " "))
+	return next(c)
+}
+
+func isAPIKeyMethodAllowed(scopes []string, method string) bool {
+	for _, scope := range scopes {
+		if scope == string(enum.APIKeyScope.Write) {
+			return true
+		}
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		for _, scope := range scopes {
+			if scope == string(enum.APIKeyScope.Read) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validateAccessToken checks signature, expiry and the kind of token, then rejects tokens whose jti or session
+// was revoked
+func (m *AuthMiddleware) validateAccessToken(c echo.Context, tokenUses ...enum.TokenUseValue) (*helper.TokenDetails, error) {
+	// Get the Authorization header value
+	token, err := extractTokenFromHeaderString(c.Request().Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := helper.ValidateAccessToken(token, tokenUses...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range []string{claims.TokenID, claims.SessionID} {
+		if id == "" {
+			continue
+		}
+
+		revoked, err := m.revokedTokenStorage.IsTokenRevoked(id)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, errors.New("access token is revoked")
+		}
+	}
+
+	return claims, nil
+}
+
+// setTokenClaims leaves "userId" empty for client tokens so user handlers never treat a client id as a user
+func setTokenClaims(c echo.Context, claims *helper.TokenDetails) {
+	c.Set("callerType", claims.CallerType())
+	if claims.CallerType() == enum.CallerType.User {
+		c.Set("userId", claims.UserID)
+	}
+	c.Set("sessionId", claims.SessionID)
+	c.Set("tokenId", claims.TokenID)
+	c.Set("clientId", claims.ClientID)
+	c.Set("scope", claims.Scope)
+	if claims.ExpiredIn != nil {
+		c.Set("tokenExpiredIn", *claims.ExpiredIn)
+	}
+}
+
 func extractTokenFromHeaderString(header string) (string, error) {
 	parts := strings.Split(header, " ")
 	if len(parts) < 2 || parts[0] != "Bearer" || strings.TrimSpace(parts[1]) == "" {
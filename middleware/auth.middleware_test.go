@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"realworld-authentication/config/env"
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type fakeRevokedTokenStorage struct{}
+
+func (f *fakeRevokedTokenStorage) RevokeToken(tokenID string, expiredTime time.Time) error {
+	return nil
+}
+
+func (f *fakeRevokedTokenStorage) IsTokenRevoked(tokenID string) (bool, error) {
+	return false, nil
+}
+
+func loadTestConfig(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("access_token_key: test-access-token-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.LoadConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	helper.AccessTokenKeySet.SetKeys(helper.NewHMACSigningKey(env.AppConfig.AccessTokenKey))
+}
+
+// serveWithToken runs TokenAuthMiddleware with the bearer token and returns the caller seen by the handler
+func serveWithToken(t *testing.T, token string) (int, interface{}, interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/upload", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	var callerType, userID interface{}
+	m := NewAuthMiddleware(nil, &fakeRevokedTokenStorage{}, nil)
+	err := m.TokenAuthMiddleware(func(c echo.Context) error {
+		callerType = c.Get("callerType")
+		userID = c.Get("userId")
+		return c.NoContent(http.StatusOK)
+	})(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rec.Code, callerType, userID
+}
+
+func TestTokenAuthMiddlewareAcceptsClientToken(t *testing.T) {
+	loadTestConfig(t)
+
+	clientToken, err := helper.GenerateClientAccessJWT("client-1", "files:write", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, callerType, userID := serveWithToken(t, *clientToken.Token)
+	if status != http.StatusOK || callerType != enum.CallerType.Client {
+		t.Fatalf("client token: status = %d, callerType = %v", status, callerType)
+	}
+	if userID != nil {
+		t.Fatalf("client token set userId = %v", userID)
+	}
+
+	userToken, err := helper.GenerateAccessJWT("user-1", "session-1", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, callerType, userID = serveWithToken(t, *userToken.Token)
+	if status != http.StatusOK || callerType != enum.CallerType.User || userID != "user-1" {
+		t.Fatalf("user token: status = %d, callerType = %v, userId = %v", status, callerType, userID)
+	}
+}
+
+func TestTokenAuthMiddlewareRejectsRelyingPartyToken(t *testing.T) {
+	loadTestConfig(t)
+
+	oauthToken, err := helper.GenerateOAuthAccessJWT("user-1", "client-1", "openid", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, _, _ := serveWithToken(t, *oauthToken.Token); status != http.StatusUnauthorized {
+		t.Fatalf("relying party token: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
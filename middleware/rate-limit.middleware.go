@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"realworld-authentication/config/env"
+	"realworld-authentication/helper"
+	"realworld-authentication/model/enum"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+
+	RATE_LIMIT_MAX_BODY_BYTES = 1 << 20
+)
+
+type RateLimitStorage interface {
+	TakeToken(key string, limit int64, period time.Duration) (*helper.RateLimitResult, error)
+}
+
+type RateLimitMiddleware struct {
+	storage  RateLimitStorage
+	enabled  bool
+	policies map[string]env.RateLimitPolicyConfig
+}
+
+func NewRateLimitMiddleware(storage RateLimitStorage, enabled bool, policies map[string]env.RateLimitPolicyConfig) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		storage:  storage,
+		enabled:  enabled,
+		policies: policies,
+	}
+}
+
+// Limit applies the named policy to a route, a user id policy must be added after TokenAuthMiddleware.
+// Requests are let through when the store cannot be reached so an outage of the shared store does not block logins
+func (m *RateLimitMiddleware) Limit(policyName string) echo.MiddlewareFunc {
+	policy, ok := m.policies[policyName]
+	if !ok || policy.Limit <= 0 || policy.Period <= 0 {
+		if m.enabled {
+			log.Printf("rate limit policy %s is not configured, the route is not limited", policyName)
+		}
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !m.enabled {
+				return next(c)
+			}
+
+			key := fmt.Sprintf("%s:%s", policyName, getRateLimitKey(c, policy.Key))
+			result, err := m.storage.TakeToken(key, policy.Limit, policy.Period*time.Minute)
+			if err != nil {
+				log.Printf("rate limit %s: %v", policyName, err)
+				return next(c)
+			}
+
+			setRateLimitHeaders(c, policy, result)
+			if !result.Allowed {
+				c.Response().Header().Set(echo.HeaderRetryAfter, formatSeconds(result.RetryAfter))
+				return c.JSON(http.StatusTooManyRequests, &helper.APIResponse{
+					Status:    helper.APIStatus.Invalid,
+					Message:   "Too many requests, try again later",
+					ErrorCode: string(enum.ErrorCodeTooManyRequests.RateLimit),
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// getRateLimitKey falls back to the ip address when the request has no email or user id, so leaving them out
+// does not escape the limit
+func getRateLimitKey(c echo.Context, key enum.RateLimitKeyValue) string {
+	switch key {
+	case enum.RateLimitKey.Email:
+		if email := getRequestEmail(c); email != "" {
+			return string(key) + ":" + helper.HashToken(email)
+		}
+	case enum.RateLimitKey.UserID:
+		if userID, ok := c.Get("userId").(string); ok && userID != "" {
+			return string(key) + ":" + userID
+		}
+	}
+
+	return string(enum.RateLimitKey.IPAddress) + ":" + c.RealIP()
+}
+
+// getRequestEmail reads the email from the query or the json body, the body is restored for the handler
+func getRequestEmail(c echo.Context) string {
+	if email := c.QueryParam("email"); email != "" {
+		return strings.ToLower(strings.TrimSpace(email))
+	}
+
+	req := c.Request()
+	if req.Body == nil || !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, RATE_LIMIT_MAX_BODY_BYTES))
+	if err != nil {
+		return ""
+	}
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+
+	var input struct {
+		Email string `json:"email"`
+		User  struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ""
+	}
+
+	email := input.User.Email
+	if email == "" {
+		email = input.Email
+	}
+
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// setRateLimitHeaders keeps the headers of the most restrictive policy when several policies limit the route
+func setRateLimitHeaders(c echo.Context, policy env.RateLimitPolicyConfig, result *helper.RateLimitResult) {
+	header := c.Response().Header()
+	if current := header.Get(HeaderRateLimitRemaining); current != "" {
+		if remaining, err := strconv.ParseInt(current, 10, 64); err == nil && remaining <= result.Remaining {
+			return
+		}
+	}
+
+	header.Set(HeaderRateLimitLimit, strconv.FormatInt(result.Limit, 10))
+	header.Set(HeaderRateLimitRemaining, strconv.FormatInt(result.Remaining, 10))
+	header.Set(HeaderRateLimitReset, formatSeconds(result.Reset))
+	header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%s", result.Limit, formatSeconds(policy.Period*time.Minute)))
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKey struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	KeyID        string     `json:"keyId,omitempty" bson:"key_id,omitempty"`
+	UserID       string     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	Name         string     `json:"name,omitempty" bson:"name,omitempty"`
+	Prefix       string     `json:"prefix,omitempty" bson:"prefix,omitempty"`
+	KeyHash      string     `json:"-" bson:"key_hash,omitempty"`
+	Scopes       []string   `json:"scopes,omitempty" bson:"scopes,omitempty"`
+	LastUsedTime *time.Time `json:"lastUsedTime,omitempty" bson:"last_used_time,omitempty"`
+	ExpiredTime  *time.Time `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditLog struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	UserID    string               `json:"userId,omitempty" bson:"user_id,omitempty"`
+	Event     enum.AuditEventValue `json:"event,omitempty" bson:"event,omitempty"`
+	IPAddress string               `json:"ipAddress,omitempty" bson:"ip_address,omitempty"`
+	Detail    string               `json:"detail,omitempty" bson:"detail,omitempty"`
+}
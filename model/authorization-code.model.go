@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuthorizationCode struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	CodeHash            string     `json:"-" bson:"code_hash,omitempty"`
+	ClientID            string     `json:"clientId,omitempty" bson:"client_id,omitempty"`
+	UserID              string     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	RedirectURI         string     `json:"redirectUri,omitempty" bson:"redirect_uri,omitempty"`
+	Scope               string     `json:"scope,omitempty" bson:"scope,omitempty"`
+	Nonce               string     `json:"nonce,omitempty" bson:"nonce,omitempty"`
+	CodeChallenge       string     `json:"-" bson:"code_challenge,omitempty"`
+	CodeChallengeMethod string     `json:"-" bson:"code_challenge_method,omitempty"`
+	ExpiredTime         *time.Time `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+	UsedTime            *time.Time `json:"usedTime,omitempty" bson:"used_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Client struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	ClientID         string   `json:"clientId,omitempty" bson:"client_id,omitempty"`
+	ClientSecretHash string   `json:"-" bson:"client_secret_hash,omitempty"`
+	Name             string   `json:"name,omitempty" bson:"name,omitempty"`
+	RedirectURIs     []string `json:"redirectUris,omitempty" bson:"redirect_uris,omitempty"`
+	Scopes           []string `json:"scopes,omitempty" bson:"scopes,omitempty"`
+	Public           *bool    `json:"public,omitempty" bson:"public,omitempty"`
+
+	// clients registered without grant types only use the authorization code flow
+	GrantTypes []enum.OAuthGrantTypeValue `json:"grantTypes,omitempty" bson:"grant_types,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
@@ -0,0 +1,14 @@
+package enum
+
+type APIKeyScopeValue string
+
+type apiKeyScope struct {
+	Read  APIKeyScopeValue
+	Write APIKeyScopeValue
+}
+
+// read keys are limited to safe http methods, write keys may call every endpoint of their user
+var APIKeyScope = &apiKeyScope{
+	Read:  "read",
+	Write: "write",
+}
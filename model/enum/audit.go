@@ -0,0 +1,19 @@
+package enum
+
+type AuditEventValue string
+
+type auditEvent struct {
+	RefreshTokenReuse      AuditEventValue
+	IdentityLinked         AuditEventValue
+	IdentityUnlinked       AuditEventValue
+	AccountLocked          AuditEventValue
+	PasswordChangeRequired AuditEventValue
+}
+
+var AuditEvent = &auditEvent{
+	RefreshTokenReuse:      "REFRESH_TOKEN_REUSE",
+	IdentityLinked:         "IDENTITY_LINKED",
+	IdentityUnlinked:       "IDENTITY_UNLINKED",
+	AccountLocked:          "ACCOUNT_LOCKED",
+	PasswordChangeRequired: "PASSWORD_CHANGE_REQUIRED",
+}
@@ -0,0 +1,13 @@
+package enum
+
+type BreachedPasswordActionValue string
+
+type breachedPasswordAction struct {
+	Reject BreachedPasswordActionValue
+	Warn   BreachedPasswordActionValue
+}
+
+var BreachedPasswordAction = &breachedPasswordAction{
+	Reject: "reject",
+	Warn:   "warn",
+}
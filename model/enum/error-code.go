@@ -35,6 +35,20 @@ type (
 	errorCodeNotExisted struct {
 		User ErrorCodeEnumValue
 	}
+
+	errorCodeReusedEnum struct {
+		Password ErrorCodeEnumValue
+	}
+
+	errorCodeLockedEnum struct {
+		Account   ErrorCodeEnumValue
+		IPAddress ErrorCodeEnumValue
+	}
+
+	errorCodeTooManyRequestsEnum struct {
+		Login     ErrorCodeEnumValue
+		RateLimit ErrorCodeEnumValue
+	}
 )
 
 var (
@@ -70,4 +84,18 @@ var (
 	ErrorCodeNotExisted = &errorCodeNotExisted{
 		User: "NOT_EXISTED_USER",
 	}
+
+	ErrorCodeReused = &errorCodeReusedEnum{
+		Password: "REUSED_PASSWORD",
+	}
+
+	ErrorCodeLocked = &errorCodeLockedEnum{
+		Account:   "LOCKED_ACCOUNT",
+		IPAddress: "LOCKED_IP_ADDRESS",
+	}
+
+	ErrorCodeTooManyRequests = &errorCodeTooManyRequestsEnum{
+		Login:     "TOO_MANY_LOGIN_ATTEMPTS",
+		RateLimit: "RATE_LIMIT_EXCEEDED",
+	}
 )
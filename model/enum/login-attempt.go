@@ -0,0 +1,13 @@
+package enum
+
+type LoginAttemptTypeValue string
+
+type loginAttemptType struct {
+	Account   LoginAttemptTypeValue
+	IPAddress LoginAttemptTypeValue
+}
+
+var LoginAttemptType = &loginAttemptType{
+	Account:   "ACCOUNT",
+	IPAddress: "IP_ADDRESS",
+}
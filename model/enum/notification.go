@@ -0,0 +1,29 @@
+package enum
+
+type NotificationTypeValue string
+
+type notificationType struct {
+	ResetPassword NotificationTypeValue
+	VerifyEmail   NotificationTypeValue
+	MagicLink     NotificationTypeValue
+	AccountLocked NotificationTypeValue
+}
+
+var NotificationType = &notificationType{
+	ResetPassword: "reset-password",
+	VerifyEmail:   "verify-email",
+	MagicLink:     "magic-link",
+	AccountLocked: "account-locked",
+}
+
+type NotificationDriverValue string
+
+type notificationDriver struct {
+	Log  NotificationDriverValue
+	SMTP NotificationDriverValue
+}
+
+var NotificationDriver = &notificationDriver{
+	Log:  "log",
+	SMTP: "smtp",
+}
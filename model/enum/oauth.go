@@ -0,0 +1,87 @@
+package enum
+
+type OAuthScopeValue string
+
+type oauthScope struct {
+	OpenID  OAuthScopeValue
+	Email   OAuthScopeValue
+	Profile OAuthScopeValue
+}
+
+var OAuthScope = &oauthScope{
+	OpenID:  "openid",
+	Email:   "email",
+	Profile: "profile",
+}
+
+type OAuthGrantTypeValue string
+
+type oauthGrantType struct {
+	AuthorizationCode OAuthGrantTypeValue
+	ClientCredentials OAuthGrantTypeValue
+}
+
+var OAuthGrantType = &oauthGrantType{
+	AuthorizationCode: "authorization_code",
+	ClientCredentials: "client_credentials",
+}
+
+// CallerTypeValue tells whether an access token acts for a user or for a service client
+type CallerTypeValue string
+
+type callerType struct {
+	User   CallerTypeValue
+	Client CallerTypeValue
+}
+
+var CallerType = &callerType{
+	User:   "user",
+	Client: "client",
+}
+
+// TokenUseValue is the "token_use" claim of the tokens signed with the access token keys, only first party access
+// tokens open a user session on this api, client access tokens let a service call it as itself
+type TokenUseValue string
+
+type tokenUse struct {
+	Access       TokenUseValue
+	ClientAccess TokenUseValue
+	OAuthAccess  TokenUseValue
+	ID           TokenUseValue
+}
+
+var TokenUse = &tokenUse{
+	Access:       "access",
+	ClientAccess: "client_access",
+	OAuthAccess:  "oauth_access",
+	ID:           "id",
+}
+
+// OAuthErrorCodeValue are the error codes of RFC 6749 section 4.1.2.1 and 5.2
+type OAuthErrorCodeValue string
+
+type oauthErrorCode struct {
+	InvalidRequest          OAuthErrorCodeValue
+	InvalidClient           OAuthErrorCodeValue
+	InvalidGrant            OAuthErrorCodeValue
+	InvalidScope            OAuthErrorCodeValue
+	UnauthorizedClient      OAuthErrorCodeValue
+	UnsupportedGrantType    OAuthErrorCodeValue
+	UnsupportedResponseType OAuthErrorCodeValue
+	AccessDenied            OAuthErrorCodeValue
+	InsufficientScope       OAuthErrorCodeValue
+	ServerError             OAuthErrorCodeValue
+}
+
+var OAuthErrorCode = &oauthErrorCode{
+	InvalidRequest:          "invalid_request",
+	InvalidClient:           "invalid_client",
+	InvalidGrant:            "invalid_grant",
+	InvalidScope:            "invalid_scope",
+	UnauthorizedClient:      "unauthorized_client",
+	UnsupportedGrantType:    "unsupported_grant_type",
+	UnsupportedResponseType: "unsupported_response_type",
+	AccessDenied:            "access_denied",
+	InsufficientScope:       "insufficient_scope",
+	ServerError:             "server_error",
+}
@@ -0,0 +1,27 @@
+package enum
+
+type PasswordRuleValue string
+
+type passwordRule struct {
+	MinLength        PasswordRuleValue
+	MaxLength        PasswordRuleValue
+	Lowercase        PasswordRuleValue
+	Uppercase        PasswordRuleValue
+	Digit            PasswordRuleValue
+	Symbol           PasswordRuleValue
+	ContainsUserInfo PasswordRuleValue
+	Strength         PasswordRuleValue
+	Breached         PasswordRuleValue
+}
+
+var PasswordRule = &passwordRule{
+	MinLength:        "MIN_LENGTH",
+	MaxLength:        "MAX_LENGTH",
+	Lowercase:        "LOWERCASE",
+	Uppercase:        "UPPERCASE",
+	Digit:            "DIGIT",
+	Symbol:           "SYMBOL",
+	ContainsUserInfo: "CONTAINS_USER_INFO",
+	Strength:         "STRENGTH",
+	Breached:         "BREACHED",
+}
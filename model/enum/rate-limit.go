@@ -0,0 +1,15 @@
+package enum
+
+type RateLimitKeyValue string
+
+type rateLimitKey struct {
+	IPAddress RateLimitKeyValue
+	Email     RateLimitKeyValue
+	UserID    RateLimitKeyValue
+}
+
+var RateLimitKey = &rateLimitKey{
+	IPAddress: "ip",
+	Email:     "email",
+	UserID:    "user_id",
+}
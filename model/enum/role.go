@@ -5,11 +5,13 @@ type UserRoleValue string
 type userRole struct {
 	User   UserRoleValue
 	Author UserRoleValue
+	Admin  UserRoleValue
 }
 
 var UserRole = &userRole{
 	User:   "USER",
 	Author: "AUTHOR",
+	Admin:  "ADMIN",
 }
 
 type ProviderNameValue string
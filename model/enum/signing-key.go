@@ -0,0 +1,16 @@
+package enum
+
+type SigningKeyStatusValue string
+
+type signingKeyStatus struct {
+	Pending  SigningKeyStatusValue
+	Active   SigningKeyStatusValue
+	Inactive SigningKeyStatusValue
+}
+
+// pending keys are published for verification before promotion, inactive keys only verify until they expire
+var SigningKeyStatus = &signingKeyStatus{
+	Pending:  "PENDING",
+	Active:   "ACTIVE",
+	Inactive: "INACTIVE",
+}
@@ -8,11 +8,13 @@ var (
 type UserStatusValue string
 
 type userStatusEnum struct {
+	Pending  UserStatusValue
 	Active   UserStatusValue
 	Inactive UserStatusValue
 }
 
 var UserStatus = &userStatusEnum{
+	Pending:  "PENDING",
 	Active:   "ACTIVE",
 	Inactive: "INACTIVE",
 }
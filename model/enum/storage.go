@@ -0,0 +1,15 @@
+package enum
+
+type StorageDriverValue string
+
+type storageDriver struct {
+	Memory StorageDriverValue
+	Mongo  StorageDriverValue
+	Redis  StorageDriverValue
+}
+
+var StorageDriver = &storageDriver{
+	Memory: "memory",
+	Mongo:  "mongo",
+	Redis:  "redis",
+}
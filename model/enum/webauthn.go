@@ -0,0 +1,27 @@
+package enum
+
+type WebAuthnCeremonyValue string
+
+type webAuthnCeremony struct {
+	Registration WebAuthnCeremonyValue
+	Login        WebAuthnCeremonyValue
+	SecondFactor WebAuthnCeremonyValue
+}
+
+var WebAuthnCeremony = &webAuthnCeremony{
+	Registration: "REGISTRATION",
+	Login:        "LOGIN",
+	SecondFactor: "SECOND_FACTOR",
+}
+
+type TwoFactorMethodValue string
+
+type twoFactorMethod struct {
+	TOTP     TwoFactorMethodValue
+	WebAuthn TwoFactorMethodValue
+}
+
+var TwoFactorMethod = &twoFactorMethod{
+	TOTP:     "totp",
+	WebAuthn: "webauthn",
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginAttempt counts the consecutive failed logins of an account or an ip address, it is removed after the
+// failure window so old failures are forgotten
+type LoginAttempt struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	Type           enum.LoginAttemptTypeValue `json:"type,omitempty" bson:"type,omitempty"`
+	Value          string                     `json:"value,omitempty" bson:"value,omitempty"`
+	FailedCount    int64                      `json:"failedCount,omitempty" bson:"failed_count,omitempty"`
+	LastFailedTime *time.Time                 `json:"lastFailedTime,omitempty" bson:"last_failed_time,omitempty"`
+	LockedUntil    *time.Time                 `json:"lockedUntil,omitempty" bson:"locked_until,omitempty"`
+	ExpiredTime    *time.Time                 `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
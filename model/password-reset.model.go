@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PasswordReset struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	UserID      string     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	TokenHash   string     `json:"-" bson:"token_hash,omitempty"`
+	ExpiredTime *time.Time `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+	UsedTime    *time.Time `json:"usedTime,omitempty" bson:"used_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
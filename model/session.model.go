@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Session struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	SessionID        string     `json:"sessionId,omitempty" bson:"session_id,omitempty"`
+	UserID           string     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	DeviceName       string     `json:"deviceName,omitempty" bson:"device_name,omitempty"`
+	UserAgent        string     `json:"userAgent,omitempty" bson:"user_agent,omitempty"`
+	IPAddress        string     `json:"ipAddress,omitempty" bson:"ip_address,omitempty"`
+	RefreshTokenHash string     `json:"-" bson:"refresh_token_hash,omitempty"`
+	LastUsedTime     *time.Time `json:"lastUsedTime,omitempty" bson:"last_used_time,omitempty"`
+	ExpiredTime      *time.Time `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SigningKey struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	KeyID           string                     `json:"keyId,omitempty" bson:"key_id,omitempty"`
+	Algorithm       string                     `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
+	PrivateKey      string                     `json:"-" bson:"private_key,omitempty"`
+	Status          enum.SigningKeyStatusValue `json:"status,omitempty" bson:"status,omitempty"`
+	ActivatedTime   *time.Time                 `json:"activatedTime,omitempty" bson:"activated_time,omitempty"`
+	DeactivatedTime *time.Time                 `json:"deactivatedTime,omitempty" bson:"deactivated_time,omitempty"`
+	ExpiredTime     *time.Time                 `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
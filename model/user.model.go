@@ -18,15 +18,45 @@ type User struct {
 	Username       string                 `json:"username,omitempty" bson:"username,omitempty"`
 	HashedPassword string                 `json:"-" bson:"hashed_password,omitempty"`
 	Role           enum.UserRoleValue     `json:"role,omitempty" bson:"role,omitempty"`
-	RefreshToken   string                 `json:"-" bson:"refresh_token,omitempty"`
 	Status         enum.UserStatusValue   `json:"status,omitempty" bson:"status,omitempty"`
+	EmailVerified  *bool                  `json:"emailVerified,omitempty" bson:"email_verified,omitempty"`
 	Provider       enum.ProviderNameValue `json:"provider,omitempty" bson:"provider,omitempty"`
 	Bio            *string                `json:"bio,omitempty" bson:"bio,omitempty"`
 	Avatar         *primitive.ObjectID    `json:"avatar,omitempty" bson:"avatar,omitempty"`
 
+	// previous bcrypt hashes, newest first, the current password is not part of it
+	PasswordHistory []string `json:"-" bson:"password_history,omitempty"`
+
+	// password expiry, an admin can require a change before the password expires
+	PasswordChangedAt      *time.Time `json:"-" bson:"password_changed_at,omitempty"`
+	PasswordChangeRequired *bool      `json:"-" bson:"password_change_required,omitempty"`
+
+	// email verification
+	EmailVerificationSentTime *time.Time `json:"-" bson:"email_verification_sent_time,omitempty"`
+
+	// magic link login
+	MagicLinkSentTime *time.Time `json:"-" bson:"magic_link_sent_time,omitempty"`
+
+	// two factor authentication
+	TwoFactorEnabled       *bool    `json:"-" bson:"two_factor_enabled,omitempty"`
+	TwoFactorSecret        string   `json:"-" bson:"two_factor_secret,omitempty"`
+	TwoFactorLastUsedStep  int64    `json:"-" bson:"two_factor_last_used_step,omitempty"`
+	TwoFactorRecoveryCodes []string `json:"-" bson:"two_factor_recovery_codes,omitempty"`
+
+	// linked login providers, a provider account can only belong to one user. The user is served as the public
+	// profile, so identities and the security settings above are only returned by their own endpoints
+	Identities []*UserIdentity `json:"-" bson:"identities,omitempty"`
+
 	// for fe view
 	AccessToken string `json:"accessToken,omitempty" bson:"-"`
 
 	// for query
 	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
 }
+
+type UserIdentity struct {
+	Provider   string     `json:"provider,omitempty" bson:"provider,omitempty"`
+	Subject    string     `json:"subject,omitempty" bson:"subject,omitempty"`
+	Email      string     `json:"email,omitempty" bson:"email,omitempty"`
+	LinkedTime *time.Time `json:"linkedTime,omitempty" bson:"linked_time,omitempty"`
+}
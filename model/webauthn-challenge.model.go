@@ -0,0 +1,24 @@
+package model
+
+import (
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebAuthnChallenge struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	Challenge   string                     `json:"-" bson:"challenge,omitempty"`
+	Ceremony    enum.WebAuthnCeremonyValue `json:"ceremony,omitempty" bson:"ceremony,omitempty"`
+	UserID      string                     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	ExpiredTime *time.Time                 `json:"expiredTime,omitempty" bson:"expired_time,omitempty"`
+	UsedTime    *time.Time                 `json:"usedTime,omitempty" bson:"used_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebAuthnCredential struct {
+	ID              *primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	CreatedTime     *time.Time          `json:"createdTime,omitempty" bson:"created_time,omitempty"`
+	LastUpdatedTime *time.Time          `json:"lastUpdatedTime,omitempty" bson:"last_updated_time,omitempty"`
+
+	CredentialID string     `json:"credentialId,omitempty" bson:"credential_id,omitempty"`
+	UserID       string     `json:"userId,omitempty" bson:"user_id,omitempty"`
+	Name         string     `json:"name,omitempty" bson:"name,omitempty"`
+	PublicKey    []byte     `json:"-" bson:"public_key,omitempty"`
+	Algorithm    int64      `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
+	SignCount    *int64     `json:"-" bson:"sign_count,omitempty"`
+	AAGUID       string     `json:"aaguid,omitempty" bson:"aaguid,omitempty"`
+	Transports   []string   `json:"transports,omitempty" bson:"transports,omitempty"`
+	LastUsedTime *time.Time `json:"lastUsedTime,omitempty" bson:"last_used_time,omitempty"`
+
+	// for query
+	ComplexQuery []*bson.M `json:"-" bson:"$and,omitempty"`
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type apiKeyStorage struct {
+	Instance *Instance
+}
+
+func NewAPIKeyStorage(db *mongo.Database) *apiKeyStorage {
+	ins := &Instance{
+		ColName:        "api_keys",
+		TemplateObject: &model.APIKey{},
+	}
+	ins.ApplyDatabase(db)
+
+	// expired keys are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "key_hash", Value: 1}}, options.Index().SetUnique(true))
+	_ = ins.CreateIndex(bson.D{{Key: "user_id", Value: 1}}, options.Index())
+
+	r := &apiKeyStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *apiKeyStorage) CreateAPIKey(data *model.APIKey) (*model.APIKey, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.APIKey)[0], nil
+}
+
+func (r *apiKeyStorage) GetAPIKeysByUserID(userID string) ([]*model.APIKey, error) {
+	dataRes, err := r.Instance.Query(model.APIKey{
+		UserID: userID,
+	}, 0, 0, &bson.M{"created_time": -1})
+	if err != nil {
+		return nil, err
+	}
+
+	if dataRes == nil {
+		return []*model.APIKey{}, nil
+	}
+
+	return dataRes.([]*model.APIKey), nil
+}
+
+func (r *apiKeyStorage) GetAPIKeyByID(userID, keyID string) (*model.APIKey, error) {
+	dataRes, err := r.Instance.QueryOne(model.APIKey{
+		UserID: userID,
+		KeyID:  keyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.APIKey)[0], nil
+}
+
+// UseAPIKey matches an unexpired key by hash and records when it was last used
+func (r *apiKeyStorage) UseAPIKey(keyHash string) (*model.APIKey, error) {
+	now := time.Now()
+	dataRes, err := r.Instance.UpdateOne(model.APIKey{
+		KeyHash: keyHash,
+		ComplexQuery: []*bson.M{
+			{
+				"$or": []bson.M{
+					{"expired_time": bson.M{"$exists": false}},
+					{"expired_time": bson.M{"$gt": now}},
+				},
+			},
+		},
+	}, &model.APIKey{
+		LastUsedTime: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.APIKey)[0], nil
+}
+
+func (r *apiKeyStorage) DeleteAPIKey(userID, keyID string) error {
+	return r.Instance.DeleteOne(model.APIKey{
+		UserID: userID,
+		KeyID:  keyID,
+	})
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type auditStorage struct {
+	Instance *Instance
+}
+
+func NewAuditStorage(db *mongo.Database) *auditStorage {
+	ins := &Instance{
+		ColName:        "audit_log",
+		TemplateObject: &model.AuditLog{},
+	}
+	ins.ApplyDatabase(db)
+
+	r := &auditStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *auditStorage) CreateAuditLog(data *model.AuditLog) (*model.AuditLog, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.AuditLog)[0], nil
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type authStorage struct {
@@ -18,6 +21,10 @@ func NewAuthStorage(db *mongo.Database) *authStorage {
 	}
 	ins.ApplyDatabase(db)
 
+	_ = ins.CreateIndex(bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}}, options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+		"identities.subject": bson.M{"$exists": true},
+	}))
+
 	r := &authStorage{
 		Instance: ins,
 	}
@@ -84,9 +91,84 @@ func (r *authStorage) GetUserByID(id string) (*model.User, error) {
 	return dataRes.([]*model.User)[0], nil
 }
 
-func (r *authStorage) UpdateUserPassword(query *model.User, password string) (*model.User, error) {
+// UpdateUserPassword replaces the password history in the same update, an empty history keeps the stored one,
+// a required password change is done once the password is updated
+func (r *authStorage) UpdateUserPassword(query *model.User, password string, passwordHistory []string) (*model.User, error) {
+	now := time.Now()
 	dataRes, err := r.Instance.UpdateOne(query, &model.User{
-		HashedPassword: password,
+		HashedPassword:         password,
+		PasswordHistory:        passwordHistory,
+		PasswordChangedAt:      &now,
+		PasswordChangeRequired: &enum.FALSE,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
+}
+
+// UpdateEmailVerificationSentTime only matches when the previous email was sent before resendAfter, it is used to throttle resending
+func (r *authStorage) UpdateEmailVerificationSentTime(userID string, sentTime time.Time, resendAfter time.Time) (*model.User, error) {
+	dataRes, err := r.Instance.UpdateOne(model.User{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"$or": []*bson.M{{
+					"email_verification_sent_time": bson.M{"$lt": resendAfter},
+				}, {
+					"email_verification_sent_time": bson.M{"$exists": false},
+				}},
+			},
+		},
+	}, &model.User{
+		EmailVerificationSentTime: &sentTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
+}
+
+// UpdateMagicLinkSentTime only matches when the previous link was sent before resendAfter, it is used to throttle magic links per email
+func (r *authStorage) UpdateMagicLinkSentTime(userID string, sentTime time.Time, resendAfter time.Time) (*model.User, error) {
+	dataRes, err := r.Instance.UpdateOne(model.User{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"$or": []*bson.M{{
+					"magic_link_sent_time": bson.M{"$lt": resendAfter},
+				}, {
+					"magic_link_sent_time": bson.M{"$exists": false},
+				}},
+			},
+		},
+	}, &model.User{
+		MagicLinkSentTime: &sentTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
+}
+
+// UpdateTwoFactorLastUsedStep only matches when step is newer than the stored one, so a code cannot be replayed
+func (r *authStorage) UpdateTwoFactorLastUsedStep(userID string, step int64) (*model.User, error) {
+	dataRes, err := r.Instance.UpdateOne(model.User{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"$or": []*bson.M{{
+					"two_factor_last_used_step": bson.M{"$lt": step},
+				}, {
+					"two_factor_last_used_step": bson.M{"$exists": false},
+				}},
+			},
+		},
+	}, &model.User{
+		TwoFactorLastUsedStep: step,
 	})
 	if err != nil {
 		return nil, err
@@ -95,8 +177,78 @@ func (r *authStorage) UpdateUserPassword(query *model.User, password string) (*m
 	return dataRes.([]*model.User)[0], nil
 }
 
-func (r *authStorage) DeleteToken(token string) error {
-	return r.Instance.DeleteOne(model.User{
-		RefreshToken: token,
+// RemoveTwoFactorRecoveryCode pulls a used recovery code, it fails when the code was already consumed
+func (r *authStorage) RemoveTwoFactorRecoveryCode(userID, hashedCode string) (*model.User, error) {
+	dataRes, err := r.Instance.UpdateOneWithOperator(model.User{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"two_factor_recovery_codes": hashedCode,
+			},
+		},
+	}, "$pull", bson.M{
+		"two_factor_recovery_codes": hashedCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
+}
+
+func (r *authStorage) GetUserByIdentity(provider, subject string) (*model.User, error) {
+	dataRes, err := r.Instance.QueryOne(model.User{
+		ComplexQuery: []*bson.M{
+			{
+				"identities": bson.M{
+					"$elemMatch": bson.M{
+						"provider": provider,
+						"subject":  subject,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
+}
+
+// AddUserIdentity fails when the user already linked an account of the same provider
+func (r *authStorage) AddUserIdentity(userID string, identity *model.UserIdentity) (*model.User, error) {
+	dataRes, err := r.Instance.UpdateOneWithOperator(model.User{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"identities.provider": bson.M{"$ne": identity.Provider},
+			},
+		},
+	}, "$push", bson.M{
+		"identities": identity,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
+}
+
+func (r *authStorage) RemoveUserIdentity(userID, provider string) (*model.User, error) {
+	dataRes, err := r.Instance.UpdateOneWithOperator(model.User{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"identities.provider": provider,
+			},
+		},
+	}, "$pull", bson.M{
+		"identities": bson.M{"provider": provider},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.User)[0], nil
 }
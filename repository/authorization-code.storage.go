@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type authorizationCodeStorage struct {
+	Instance *Instance
+}
+
+func NewAuthorizationCodeStorage(db *mongo.Database) *authorizationCodeStorage {
+	ins := &Instance{
+		ColName:        "authorization_code",
+		TemplateObject: &model.AuthorizationCode{},
+	}
+	ins.ApplyDatabase(db)
+
+	// expired codes are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "code_hash", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &authorizationCodeStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *authorizationCodeStorage) CreateAuthorizationCode(data *model.AuthorizationCode) (*model.AuthorizationCode, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.AuthorizationCode)[0], nil
+}
+
+// UseAuthorizationCode marks an unused and unexpired code as used, it fails when the code cannot be redeemed
+func (r *authorizationCodeStorage) UseAuthorizationCode(codeHash string) (*model.AuthorizationCode, error) {
+	now := time.Now()
+	dataRes, err := r.Instance.UpdateOne(model.AuthorizationCode{
+		CodeHash: codeHash,
+		ComplexQuery: []*bson.M{
+			{
+				"used_time": bson.M{"$exists": false},
+			},
+			{
+				"expired_time": bson.M{"$gt": now},
+			},
+		},
+	}, &model.AuthorizationCode{
+		UsedTime: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.AuthorizationCode)[0], nil
+}
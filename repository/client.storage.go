@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type clientStorage struct {
+	Instance *Instance
+}
+
+func NewClientStorage(db *mongo.Database) *clientStorage {
+	ins := &Instance{
+		ColName:        "clients",
+		TemplateObject: &model.Client{},
+	}
+	ins.ApplyDatabase(db)
+
+	_ = ins.CreateIndex(bson.D{{Key: "client_id", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &clientStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *clientStorage) CreateClient(data *model.Client) (*model.Client, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.Client)[0], nil
+}
+
+func (r *clientStorage) GetClientByID(clientID string) (*model.Client, error) {
+	dataRes, err := r.Instance.QueryOne(model.Client{
+		ClientID: clientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.Client)[0], nil
+}
+
+func (r *clientStorage) GetClients() ([]*model.Client, error) {
+	dataRes, err := r.Instance.Query(model.Client{}, 0, 0, &bson.M{"created_time": -1})
+	if err != nil {
+		return nil, err
+	}
+
+	if dataRes == nil {
+		return []*model.Client{}, nil
+	}
+
+	return dataRes.([]*model.Client), nil
+}
+
+func (r *clientStorage) DeleteClient(clientID string) error {
+	return r.Instance.DeleteOne(model.Client{
+		ClientID: clientID,
+	})
+}
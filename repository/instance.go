@@ -212,6 +212,82 @@ func (m *Instance) UpdateOne(query interface{}, updater interface{}, opts ...*op
 	return m.parseSingleResult(result, "UpdateOne")
 }
 
+// UpdateOneWithOperator Update one matched object with an update operator other than $set ($push, $pull, $inc...).
+func (m *Instance) UpdateOneWithOperator(query interface{}, operator string, updater interface{}, opts ...*options.FindOneAndUpdateOptions) (interface{}, error) {
+	// check col
+	if m.coll == nil {
+		return nil, fmt.Errorf("%v is not inited", m.ColName)
+	}
+
+	// convert
+	bUpdater, err := m.convertToBson(updater)
+	if err != nil {
+		return nil, err
+	}
+
+	// transform to bson
+	converted, err := m.convertToBson(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// do update
+	if opts == nil {
+		after := options.After
+		opts = []*options.FindOneAndUpdateOptions{
+			{
+				ReturnDocument: &after,
+			},
+		}
+	}
+	update := bson.M{
+		operator: bUpdater,
+		"$set":   bson.M{"last_updated_time": time.Now()},
+	}
+	result := m.coll.FindOneAndUpdate(context.TODO(), converted, update, opts...)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	return m.parseSingleResult(result, "UpdateOneWithOperator")
+}
+
+// UpdateOneWithPipeline Update one matched object with an aggregation pipeline, the stages can read the current
+// fields of the object so the whole update is applied in one atomic step.
+func (m *Instance) UpdateOneWithPipeline(query interface{}, pipeline bson.A, opts ...*options.FindOneAndUpdateOptions) (interface{}, error) {
+	// check col
+	if m.coll == nil {
+		return nil, fmt.Errorf("%v is not inited", m.ColName)
+	}
+
+	// transform to bson
+	converted, err := m.convertToBson(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// do update
+	if opts == nil {
+		after := options.After
+		opts = []*options.FindOneAndUpdateOptions{
+			{
+				ReturnDocument: &after,
+			},
+		}
+	}
+	now := time.Now()
+	update := append(pipeline, bson.M{"$set": bson.M{
+		"created_time":      bson.M{"$ifNull": bson.A{"$created_time", now}},
+		"last_updated_time": now,
+	}})
+	result := m.coll.FindOneAndUpdate(context.TODO(), converted, update, opts...)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	return m.parseSingleResult(result, "UpdateOneWithPipeline")
+}
+
 // Query Get all object in DB
 func (m *Instance) Query(query interface{}, offset int64, limit int64, sortFields *bson.M) (interface{}, error) {
 	// check col
@@ -347,3 +423,23 @@ func (m *Instance) DeleteOne(query interface{}) error {
 
 	return nil
 }
+
+func (m *Instance) DeleteMany(query interface{}) error {
+	// check col
+	if m.coll == nil {
+		return fmt.Errorf("%v is not inited", m.ColName)
+	}
+
+	// convert query
+	converted, err := m.convertToBson(query)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.coll.DeleteMany(context.TODO(), converted)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type loginAttemptStorage struct {
+	Instance *Instance
+}
+
+func NewLoginAttemptStorage(db *mongo.Database) *loginAttemptStorage {
+	ins := &Instance{
+		ColName:        "login_attempt",
+		TemplateObject: &model.LoginAttempt{},
+	}
+	ins.ApplyDatabase(db)
+
+	// counters outside the failure window are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "type", Value: 1}, {Key: "value", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &loginAttemptStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *loginAttemptStorage) GetLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) (*model.LoginAttempt, error) {
+	dataRes, err := r.Instance.QueryOne(model.LoginAttempt{
+		Type:  attemptType,
+		Value: value,
+		ComplexQuery: []*bson.M{
+			{
+				"expired_time": bson.M{"$gt": time.Now()},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.LoginAttempt)[0], nil
+}
+
+// IncreaseLoginFailure counts one more failure in a single update, the counter is created on the first failure.
+// The ttl monitor runs periodically, so a counter past its expired time starts again from one instead of continuing
+func (r *loginAttemptStorage) IncreaseLoginFailure(attemptType enum.LoginAttemptTypeValue, value string, failedTime, expiredTime time.Time) (*model.LoginAttempt, error) {
+	dataRes, err := r.Instance.UpdateOneWithPipeline(model.LoginAttempt{
+		Type:  attemptType,
+		Value: value,
+	}, bson.A{
+		bson.M{"$set": bson.M{
+			"failed_count": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$expired_time", failedTime}},
+				bson.M{"$add": bson.A{"$failed_count", 1}},
+				1,
+			}},
+			"last_failed_time": failedTime,
+			// a failure during the lock must not shorten it
+			"expired_time": bson.M{"$max": bson.A{"$expired_time", expiredTime}},
+		}},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.LoginAttempt)[0], nil
+}
+
+// LockLoginAttempt only matches a counter that is not locked yet, so the lock is applied and notified once. The
+// count starts again from zero, after the lock the full threshold of failures is needed to lock the account again
+func (r *loginAttemptStorage) LockLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string, lockedUntil, expiredTime time.Time) (*model.LoginAttempt, error) {
+	dataRes, err := r.Instance.UpdateOneWithPipeline(model.LoginAttempt{
+		Type:  attemptType,
+		Value: value,
+		ComplexQuery: []*bson.M{
+			{
+				"$or": []*bson.M{{
+					"locked_until": bson.M{"$lt": time.Now()},
+				}, {
+					"locked_until": bson.M{"$exists": false},
+				}},
+			},
+		},
+	}, bson.A{
+		bson.M{"$set": bson.M{
+			"failed_count": 0,
+			"locked_until": lockedUntil,
+			"expired_time": expiredTime,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.LoginAttempt)[0], nil
+}
+
+func (r *loginAttemptStorage) DeleteLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) error {
+	return r.Instance.DeleteOne(model.LoginAttempt{
+		Type:  attemptType,
+		Value: value,
+	})
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type magicLinkStorage struct {
+	Instance *Instance
+}
+
+func NewMagicLinkStorage(db *mongo.Database) *magicLinkStorage {
+	ins := &Instance{
+		ColName:        "magic_link",
+		TemplateObject: &model.MagicLink{},
+	}
+	ins.ApplyDatabase(db)
+
+	// expired links are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "token_hash", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &magicLinkStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *magicLinkStorage) CreateMagicLink(data *model.MagicLink) (*model.MagicLink, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.MagicLink)[0], nil
+}
+
+// UseMagicLink marks an unused and unexpired link as used, it fails when the link cannot be consumed
+func (r *magicLinkStorage) UseMagicLink(tokenHash string) (*model.MagicLink, error) {
+	now := time.Now()
+	dataRes, err := r.Instance.UpdateOne(model.MagicLink{
+		TokenHash: tokenHash,
+		ComplexQuery: []*bson.M{
+			{
+				"used_time": bson.M{"$exists": false},
+			},
+			{
+				"expired_time": bson.M{"$gt": now},
+			},
+		},
+	}, &model.MagicLink{
+		UsedTime: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.MagicLink)[0], nil
+}
+
+func (r *magicLinkStorage) DeleteMagicLinksByUserID(userID string) error {
+	return r.Instance.DeleteMany(model.MagicLink{
+		UserID: userID,
+	})
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"math"
+	"realworld-authentication/helper"
+	"sync"
+	"time"
+)
+
+const (
+	RATE_LIMIT_SWEEP_INTERVAL = time.Minute
+)
+
+type rateLimitBucket struct {
+	tokens      float64
+	updatedTime time.Time
+	fullTime    time.Time
+}
+
+// memoryRateLimitStorage keeps the token buckets of a single instance, full buckets are dropped periodically
+type memoryRateLimitStorage struct {
+	mu            sync.Mutex
+	buckets       map[string]*rateLimitBucket
+	lastSweepTime time.Time
+}
+
+func NewMemoryRateLimitStorage() *memoryRateLimitStorage {
+	return &memoryRateLimitStorage{
+		buckets:       map[string]*rateLimitBucket{},
+		lastSweepTime: time.Now(),
+	}
+}
+
+func (r *memoryRateLimitStorage) TakeToken(key string, limit int64, period time.Duration) (*helper.RateLimitResult, error) {
+	now := time.Now()
+	refillRate := float64(limit) / float64(period)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = &rateLimitBucket{
+			tokens:      float64(limit),
+			updatedTime: now,
+		}
+		r.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(limit), bucket.tokens+float64(now.Sub(bucket.updatedTime))*refillRate)
+	bucket.updatedTime = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	bucket.fullTime = now.Add(time.Duration((float64(limit) - bucket.tokens) / refillRate))
+
+	return helper.NewRateLimitResult(allowed, limit, period, bucket.tokens), nil
+}
+
+// sweep removes the buckets refilled to their limit, they behave like a missing bucket
+func (r *memoryRateLimitStorage) sweep(now time.Time) {
+	if now.Sub(r.lastSweepTime) < RATE_LIMIT_SWEEP_INTERVAL {
+		return
+	}
+
+	for key, bucket := range r.buckets {
+		if !now.Before(bucket.fullTime) {
+			delete(r.buckets, key)
+		}
+	}
+	r.lastSweepTime = now
+}
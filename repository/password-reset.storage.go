@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type passwordResetStorage struct {
+	Instance *Instance
+}
+
+func NewPasswordResetStorage(db *mongo.Database) *passwordResetStorage {
+	ins := &Instance{
+		ColName:        "password_reset",
+		TemplateObject: &model.PasswordReset{},
+	}
+	ins.ApplyDatabase(db)
+
+	// expired tokens are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "token_hash", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &passwordResetStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *passwordResetStorage) CreatePasswordReset(data *model.PasswordReset) (*model.PasswordReset, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.PasswordReset)[0], nil
+}
+
+// GetPasswordReset only returns a token that can still be used
+func (r *passwordResetStorage) GetPasswordReset(tokenHash string) (*model.PasswordReset, error) {
+	dataRes, err := r.Instance.QueryOne(model.PasswordReset{
+		TokenHash: tokenHash,
+		ComplexQuery: []*bson.M{
+			{
+				"used_time": bson.M{"$exists": false},
+			},
+			{
+				"expired_time": bson.M{"$gt": time.Now()},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.PasswordReset)[0], nil
+}
+
+// UsePasswordReset marks an unused and unexpired token as used, it fails when the token cannot be consumed
+func (r *passwordResetStorage) UsePasswordReset(tokenHash string) (*model.PasswordReset, error) {
+	now := time.Now()
+	dataRes, err := r.Instance.UpdateOne(model.PasswordReset{
+		TokenHash: tokenHash,
+		ComplexQuery: []*bson.M{
+			{
+				"used_time": bson.M{"$exists": false},
+			},
+			{
+				"expired_time": bson.M{"$gt": now},
+			},
+		},
+	}, &model.PasswordReset{
+		UsedTime: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.PasswordReset)[0], nil
+}
+
+func (r *passwordResetStorage) DeletePasswordResetsByUserID(userID string) error {
+	return r.Instance.DeleteMany(model.PasswordReset{
+		UserID: userID,
+	})
+}
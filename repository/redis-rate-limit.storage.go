@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"realworld-authentication/helper"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	RATE_LIMIT_KEY_PREFIX = "rate_limit:"
+)
+
+// takeTokenScript refills and takes from the bucket in one step so every instance sees the same count,
+// the bucket expires once it would be full again
+var takeTokenScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_time")
+local tokens = tonumber(bucket[1]) or limit
+local updated_time = tonumber(bucket[2]) or now
+
+tokens = math.min(limit, tokens + math.max(0, now - updated_time) * limit / period)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_time", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((limit - tokens) * period / limit) + 1)
+
+return {allowed, tostring(tokens)}
+`)
+
+type redisRateLimitStorage struct {
+	client *redis.Client
+}
+
+func NewRedisRateLimitStorage(client *redis.Client) *redisRateLimitStorage {
+	return &redisRateLimitStorage{
+		client: client,
+	}
+}
+
+func (r *redisRateLimitStorage) TakeToken(key string, limit int64, period time.Duration) (*helper.RateLimitResult, error) {
+	values, err := takeTokenScript.Run(context.TODO(), r.client, []string{RATE_LIMIT_KEY_PREFIX + key},
+		limit, period.Milliseconds(), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensValue, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensValue, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return helper.NewRateLimitResult(allowed == 1, limit, period, tokens), nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	REVOKED_TOKEN_KEY_PREFIX = "revoked_token:"
+)
+
+type redisRevokedTokenStorage struct {
+	client *redis.Client
+}
+
+func NewRedisRevokedTokenStorage(client *redis.Client) *redisRevokedTokenStorage {
+	return &redisRevokedTokenStorage{
+		client: client,
+	}
+}
+
+func (r *redisRevokedTokenStorage) RevokeToken(tokenID string, expiredTime time.Time) error {
+	ttl := time.Until(expiredTime)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return r.client.Set(context.TODO(), REVOKED_TOKEN_KEY_PREFIX+tokenID, 1, ttl).Err()
+}
+
+func (r *redisRevokedTokenStorage) IsTokenRevoked(tokenID string) (bool, error) {
+	count, err := r.client.Exists(context.TODO(), REVOKED_TOKEN_KEY_PREFIX+tokenID).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type revokedTokenStorage struct {
+	Instance *Instance
+}
+
+func NewRevokedTokenStorage(db *mongo.Database) *revokedTokenStorage {
+	ins := &Instance{
+		ColName:        "revoked_token",
+		TemplateObject: &model.RevokedToken{},
+	}
+	ins.ApplyDatabase(db)
+
+	// a revoked token is kept until it would have expired anyway
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "token_id", Value: 1}}, options.Index())
+
+	r := &revokedTokenStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *revokedTokenStorage) RevokeToken(tokenID string, expiredTime time.Time) error {
+	_, err := r.Instance.Create(&model.RevokedToken{
+		TokenID:     tokenID,
+		ExpiredTime: &expiredTime,
+	})
+
+	return err
+}
+
+func (r *revokedTokenStorage) IsTokenRevoked(tokenID string) (bool, error) {
+	count, err := r.Instance.Count(model.RevokedToken{
+		TokenID: tokenID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count.(int64) > 0, nil
+}
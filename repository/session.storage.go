@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type sessionStorage struct {
+	Instance *Instance
+}
+
+func NewSessionStorage(db *mongo.Database) *sessionStorage {
+	ins := &Instance{
+		ColName:        "sessions",
+		TemplateObject: &model.Session{},
+	}
+	ins.ApplyDatabase(db)
+
+	// sessions whose refresh token expired are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "session_id", Value: 1}}, options.Index().SetUnique(true))
+	_ = ins.CreateIndex(bson.D{{Key: "user_id", Value: 1}}, options.Index())
+
+	r := &sessionStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *sessionStorage) CreateSession(data *model.Session) (*model.Session, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.Session)[0], nil
+}
+
+func (r *sessionStorage) GetSessionByID(sessionID string) (*model.Session, error) {
+	dataRes, err := r.Instance.QueryOne(model.Session{
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.Session)[0], nil
+}
+
+func (r *sessionStorage) GetSessionsByUserID(userID string) ([]*model.Session, error) {
+	dataRes, err := r.Instance.Query(model.Session{
+		UserID: userID,
+	}, 0, 0, &bson.M{"last_used_time": -1})
+	if err != nil {
+		return nil, err
+	}
+
+	if dataRes == nil {
+		return []*model.Session{}, nil
+	}
+
+	return dataRes.([]*model.Session), nil
+}
+
+// RotateSessionRefreshToken only matches when currentTokenHash is still the latest refresh token of the session
+func (r *sessionStorage) RotateSessionRefreshToken(sessionID, currentTokenHash string, data *model.Session) (*model.Session, error) {
+	dataRes, err := r.Instance.UpdateOne(model.Session{
+		SessionID:        sessionID,
+		RefreshTokenHash: currentTokenHash,
+	}, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.Session)[0], nil
+}
+
+func (r *sessionStorage) DeleteSession(userID, sessionID string) error {
+	return r.Instance.DeleteOne(model.Session{
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+}
+
+func (r *sessionStorage) DeleteOtherSessions(userID, currentSessionID string) error {
+	return r.Instance.DeleteMany(model.Session{
+		UserID: userID,
+		ComplexQuery: []*bson.M{
+			{
+				"session_id": bson.M{"$ne": currentSessionID},
+			},
+		},
+	})
+}
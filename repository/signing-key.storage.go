@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type signingKeyStorage struct {
+	Instance *Instance
+}
+
+func NewSigningKeyStorage(db *mongo.Database) *signingKeyStorage {
+	ins := &Instance{
+		ColName:        "signing_keys",
+		TemplateObject: &model.SigningKey{},
+	}
+	ins.ApplyDatabase(db)
+
+	// demoted keys are retired by mongo ttl monitor once no token signed by them can be valid
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "key_id", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &signingKeyStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *signingKeyStorage) CreateSigningKey(data *model.SigningKey) (*model.SigningKey, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.SigningKey)[0], nil
+}
+
+// GetSigningKeys skips expired keys which the ttl monitor has not removed yet, newest activation first
+func (r *signingKeyStorage) GetSigningKeys() ([]*model.SigningKey, error) {
+	dataRes, err := r.Instance.Query(model.SigningKey{
+		ComplexQuery: []*bson.M{
+			{
+				"$or": []bson.M{
+					{"expired_time": bson.M{"$exists": false}},
+					{"expired_time": bson.M{"$gt": time.Now()}},
+				},
+			},
+		},
+	}, 0, 0, &bson.M{"activated_time": -1})
+	if err != nil {
+		return nil, err
+	}
+
+	if dataRes == nil {
+		return []*model.SigningKey{}, nil
+	}
+
+	return dataRes.([]*model.SigningKey), nil
+}
+
+func (r *signingKeyStorage) GetSigningKeyByKeyID(keyID string) (*model.SigningKey, error) {
+	dataRes, err := r.Instance.QueryOne(model.SigningKey{
+		KeyID: keyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.SigningKey)[0], nil
+}
+
+// UpdateSigningKeyStatus only matches when the key is still in currentStatus, so concurrent promotions cannot both win
+func (r *signingKeyStorage) UpdateSigningKeyStatus(keyID string, currentStatus enum.SigningKeyStatusValue, data *model.SigningKey) (*model.SigningKey, error) {
+	dataRes, err := r.Instance.UpdateOne(model.SigningKey{
+		KeyID:  keyID,
+		Status: currentStatus,
+	}, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.SigningKey)[0], nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type webAuthnChallengeStorage struct {
+	Instance *Instance
+}
+
+func NewWebAuthnChallengeStorage(db *mongo.Database) *webAuthnChallengeStorage {
+	ins := &Instance{
+		ColName:        "webauthn_challenge",
+		TemplateObject: &model.WebAuthnChallenge{},
+	}
+	ins.ApplyDatabase(db)
+
+	// expired challenges are removed by mongo ttl monitor
+	_ = ins.CreateIndex(bson.D{{Key: "expired_time", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	_ = ins.CreateIndex(bson.D{{Key: "challenge", Value: 1}}, options.Index().SetUnique(true))
+
+	r := &webAuthnChallengeStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *webAuthnChallengeStorage) CreateWebAuthnChallenge(data *model.WebAuthnChallenge) (*model.WebAuthnChallenge, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.WebAuthnChallenge)[0], nil
+}
+
+// UseWebAuthnChallenge marks an unused and unexpired challenge of the ceremony as used, a challenge is only answered once
+func (r *webAuthnChallengeStorage) UseWebAuthnChallenge(challenge string, ceremony enum.WebAuthnCeremonyValue) (*model.WebAuthnChallenge, error) {
+	now := time.Now()
+	dataRes, err := r.Instance.UpdateOne(model.WebAuthnChallenge{
+		Challenge: challenge,
+		Ceremony:  ceremony,
+		ComplexQuery: []*bson.M{
+			{
+				"used_time": bson.M{"$exists": false},
+			},
+			{
+				"expired_time": bson.M{"$gt": now},
+			},
+		},
+	}, &model.WebAuthnChallenge{
+		UsedTime: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.WebAuthnChallenge)[0], nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"realworld-authentication/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type webAuthnCredentialStorage struct {
+	Instance *Instance
+}
+
+func NewWebAuthnCredentialStorage(db *mongo.Database) *webAuthnCredentialStorage {
+	ins := &Instance{
+		ColName:        "webauthn_credentials",
+		TemplateObject: &model.WebAuthnCredential{},
+	}
+	ins.ApplyDatabase(db)
+
+	_ = ins.CreateIndex(bson.D{{Key: "credential_id", Value: 1}}, options.Index().SetUnique(true))
+	_ = ins.CreateIndex(bson.D{{Key: "user_id", Value: 1}}, options.Index())
+
+	r := &webAuthnCredentialStorage{
+		Instance: ins,
+	}
+
+	return r
+}
+
+func (r *webAuthnCredentialStorage) CreateWebAuthnCredential(data *model.WebAuthnCredential) (*model.WebAuthnCredential, error) {
+	dataRes, err := r.Instance.Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.WebAuthnCredential)[0], nil
+}
+
+func (r *webAuthnCredentialStorage) GetWebAuthnCredentialsByUserID(userID string) ([]*model.WebAuthnCredential, error) {
+	dataRes, err := r.Instance.Query(model.WebAuthnCredential{
+		UserID: userID,
+	}, 0, 0, &bson.M{"created_time": -1})
+	if err != nil {
+		return nil, err
+	}
+
+	if dataRes == nil {
+		return []*model.WebAuthnCredential{}, nil
+	}
+
+	return dataRes.([]*model.WebAuthnCredential), nil
+}
+
+func (r *webAuthnCredentialStorage) GetWebAuthnCredentialByID(credentialID string) (*model.WebAuthnCredential, error) {
+	dataRes, err := r.Instance.QueryOne(model.WebAuthnCredential{
+		CredentialID: credentialID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.WebAuthnCredential)[0], nil
+}
+
+// UpdateWebAuthnSignCount only matches the counter read before the assertion, so two concurrent assertions
+// with the same counter cannot both succeed
+func (r *webAuthnCredentialStorage) UpdateWebAuthnSignCount(credentialID string, storedCount, signCount int64) (*model.WebAuthnCredential, error) {
+	now := time.Now()
+	dataRes, err := r.Instance.UpdateOne(model.WebAuthnCredential{
+		CredentialID: credentialID,
+		SignCount:    &storedCount,
+	}, &model.WebAuthnCredential{
+		SignCount:    &signCount,
+		LastUsedTime: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dataRes.([]*model.WebAuthnCredential)[0], nil
+}
+
+func (r *webAuthnCredentialStorage) DeleteWebAuthnCredential(userID, credentialID string) error {
+	return r.Instance.DeleteOne(model.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: credentialID,
+	})
+}
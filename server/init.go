@@ -2,12 +2,24 @@ package server
 
 import (
 	"fmt"
+	"log"
+	"net"
 	"os"
+	config_db "realworld-authentication/config/db"
+	"realworld-authentication/config/env"
 	"realworld-authentication/controller"
+	"realworld-authentication/helper"
 	auth_middleware "realworld-authentication/middleware"
+	"realworld-authentication/model/enum"
 	"realworld-authentication/repository"
+	apikey_service "realworld-authentication/service/apikey"
 	auth_service "realworld-authentication/service/auth"
 	file_service "realworld-authentication/service/file"
+	key_service "realworld-authentication/service/key"
+	notification_service "realworld-authentication/service/notification"
+	oauth_service "realworld-authentication/service/oauth"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
@@ -17,25 +29,171 @@ import (
 )
 
 type HTTPServer struct {
-	Router         *echo.Echo
-	Validator      *validator.Validate
-	AuthMiddlware  *auth_middleware.AuthMiddleware
-	FileStorage    file_service.FileStorage
-	FileService    controller.FileService
-	AuthStorage    auth_service.AuthStorage
-	AuthService    controller.AuthService
-	AuthController *controller.AuthController
+	Router                    *echo.Echo
+	Validator                 *validator.Validate
+	AuthMiddlware             *auth_middleware.AuthMiddleware
+	RateLimitMiddleware       *auth_middleware.RateLimitMiddleware
+	FileStorage               file_service.FileStorage
+	FileService               controller.FileService
+	NotificationService       controller.NotificationService
+	AuthStorage               auth_service.AuthStorage
+	PasswordResetStorage      auth_service.PasswordResetStorage
+	MagicLinkStorage          auth_service.MagicLinkStorage
+	WebAuthnCredentialStorage auth_service.WebAuthnCredentialStorage
+	WebAuthnChallengeStorage  auth_service.WebAuthnChallengeStorage
+	LoginAttemptStorage       auth_service.LoginAttemptStorage
+	AuditStorage              auth_service.AuditStorage
+	SessionStorage            auth_service.SessionStorage
+	RevokedTokenStorage       auth_service.RevokedTokenStorage
+	SigningKeyStorage         key_service.SigningKeyStorage
+	KeyService                controller.KeyService
+	KeyController             *controller.KeyController
+	ClientStorage             oauth_service.ClientStorage
+	AuthorizationCodeStorage  oauth_service.AuthorizationCodeStorage
+	OAuthService              controller.OAuthService
+	OAuthController           *controller.OAuthController
+	APIKeyStorage             apikey_service.APIKeyStorage
+	APIKeyService             controller.APIKeyService
+	APIKeyController          *controller.APIKeyController
+	AuthService               controller.AuthService
+	AuthController            *controller.AuthController
 }
 
 func (server *HTTPServer) Init(db *mongo.Database) {
 	server.Router = echo.New()
 	server.Validator = validator.New()
+	if err := checkTokenKeys(); err != nil {
+		log.Fatal(err)
+	}
+	ipExtractor, err := newIPExtractor(env.AppConfig.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.Router.IPExtractor = ipExtractor
 	server.AuthStorage = repository.NewAuthStorage(db)
+	server.PasswordResetStorage = repository.NewPasswordResetStorage(db)
+	server.MagicLinkStorage = repository.NewMagicLinkStorage(db)
+	server.WebAuthnCredentialStorage = repository.NewWebAuthnCredentialStorage(db)
+	server.WebAuthnChallengeStorage = repository.NewWebAuthnChallengeStorage(db)
+	server.LoginAttemptStorage = repository.NewLoginAttemptStorage(db)
+	server.AuditStorage = repository.NewAuditStorage(db)
+	server.SessionStorage = repository.NewSessionStorage(db)
+	server.RevokedTokenStorage = newRevokedTokenStorage(db)
 	server.FileStorage = repository.NewFileStorage(db)
-	server.AuthMiddlware = auth_middleware.NewAuthMiddleware(server.AuthStorage)
+	server.SigningKeyStorage = repository.NewSigningKeyStorage(db)
+	server.ClientStorage = repository.NewClientStorage(db)
+	server.AuthorizationCodeStorage = repository.NewAuthorizationCodeStorage(db)
+	server.APIKeyStorage = repository.NewAPIKeyStorage(db)
+	server.AuthMiddlware = auth_middleware.NewAuthMiddleware(server.AuthStorage, server.RevokedTokenStorage, server.APIKeyStorage)
+	server.RateLimitMiddleware = auth_middleware.NewRateLimitMiddleware(newRateLimitStorage(), env.AppConfig.RateLimitEnabled, env.AppConfig.RateLimitPolicies)
+	breachedPasswordDataset, err := helper.LoadBreachedPasswordDataset(env.AppConfig.BreachedPasswordDatasetDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	server.FileService = file_service.NewFileService(server.FileStorage)
-	server.AuthService = auth_service.NewAuthService(server.AuthStorage, server.FileService)
+	server.NotificationService = notification_service.NewNotificationService(notification_service.NewNotifier(env.AppConfig.NotificationDriver))
+	server.AuthService = auth_service.NewAuthService(&auth_service.Dependencies{
+		Storage:                   server.AuthStorage,
+		PasswordResetStorage:      server.PasswordResetStorage,
+		MagicLinkStorage:          server.MagicLinkStorage,
+		WebAuthnCredentialStorage: server.WebAuthnCredentialStorage,
+		WebAuthnChallengeStorage:  server.WebAuthnChallengeStorage,
+		LoginAttemptStorage:       server.LoginAttemptStorage,
+		AuditStorage:              server.AuditStorage,
+		SessionStorage:            server.SessionStorage,
+		RevokedTokenStorage:       server.RevokedTokenStorage,
+		FileService:               server.FileService,
+		NotificationService:       server.NotificationService,
+		ProviderRegistry:          helper.NewOIDCProviderRegistry(env.AppConfig.OAuthProviders),
+		BreachedPasswordDataset:   breachedPasswordDataset,
+	})
 	server.AuthController = controller.NewAuthController(server.AuthService, server.FileService, server.Validator)
+	server.OAuthService = oauth_service.NewOAuthService(server.ClientStorage, server.AuthorizationCodeStorage, server.AuthStorage)
+	server.OAuthController = controller.NewOAuthController(server.OAuthService, server.Validator)
+	server.APIKeyService = apikey_service.NewAPIKeyService(server.APIKeyStorage)
+	server.APIKeyController = controller.NewAPIKeyController(server.APIKeyService, server.Validator)
+
+	// access tokens are signed by the keyset from the signing_keys collection, other instances promote keys too
+	keyService := key_service.NewKeyService(server.SigningKeyStorage)
+	if err := keyService.LoadKeySet(); err != nil {
+		log.Fatal(err)
+	}
+	go keyService.WatchKeySet(env.AppConfig.SigningKeyRefreshIn * time.Minute)
+	if _, err := helper.IDTokenSigningKey(); err != nil {
+		log.Printf("openid connect is disabled: %v", err)
+	}
+
+	server.KeyService = keyService
+	server.KeyController = controller.NewKeyController(server.KeyService, server.Validator)
+}
+
+// checkTokenKeys refuses to start with an empty hmac key for the tokens the server signs for itself, anyone
+// could sign them otherwise
+func checkTokenKeys() error {
+	tokenKeys := []struct {
+		name  string
+		value string
+	}{
+		{"refresh_token_key", env.AppConfig.RefreshTokenKey},
+		{"two_factor_challenge_key", env.AppConfig.TwoFactorChallengeKey},
+		{"password_change_token_key", env.AppConfig.PasswordChangeTokenKey},
+		{"email_verification_key", env.AppConfig.EmailVerificationKey},
+		{"oauth_state_key", env.AppConfig.OAuthStateKey},
+	}
+
+	for _, tokenKey := range tokenKeys {
+		if tokenKey.value == "" {
+			return fmt.Errorf("%s is required", tokenKey.name)
+		}
+	}
+
+	return nil
+}
+
+// newIPExtractor makes c.RealIP() ignore forwarding headers sent by the client, they are only trusted when the
+// request comes from a configured proxy
+func newIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() == nil {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// newRevokedTokenStorage keeps the revocation list in redis when configured, mongo ttl collection otherwise
+func newRevokedTokenStorage(db *mongo.Database) auth_service.RevokedTokenStorage {
+	if env.AppConfig.RevokedTokenDriver == enum.StorageDriver.Redis {
+		return repository.NewRedisRevokedTokenStorage(config_db.RedisClient)
+	}
+
+	return repository.NewRevokedTokenStorage(db)
+}
+
+// newRateLimitStorage shares the buckets through redis when configured, they are kept in memory otherwise
+func newRateLimitStorage() auth_middleware.RateLimitStorage {
+	if env.AppConfig.RateLimitDriver == enum.StorageDriver.Redis {
+		return repository.NewRedisRateLimitStorage(config_db.RedisClient)
+	}
+
+	return repository.NewMemoryRateLimitStorage()
 }
 
 func (server *HTTPServer) UseMiddleware() {
@@ -57,9 +215,10 @@ func (server *HTTPServer) UseMiddleware() {
 	}))
 
 	server.Router.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.HEAD},
+		AllowOrigins:  []string{"*"},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, controller.HeaderDeviceName, auth_middleware.HeaderAPIKey},
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.HEAD},
+		ExposeHeaders: []string{echo.HeaderRetryAfter, auth_middleware.HeaderRateLimitLimit, auth_middleware.HeaderRateLimitRemaining, auth_middleware.HeaderRateLimitReset, auth_middleware.HeaderRateLimitPolicy},
 	}))
 }
 
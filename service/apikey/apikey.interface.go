@@ -0,0 +1,13 @@
+package apikey
+
+import (
+	"realworld-authentication/model"
+)
+
+type APIKeyStorage interface {
+	CreateAPIKey(data *model.APIKey) (*model.APIKey, error)
+	GetAPIKeysByUserID(userID string) ([]*model.APIKey, error)
+	GetAPIKeyByID(userID, keyID string) (*model.APIKey, error)
+	UseAPIKey(keyHash string) (*model.APIKey, error)
+	DeleteAPIKey(userID, keyID string) error
+}
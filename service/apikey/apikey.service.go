@@ -0,0 +1,81 @@
+package apikey
+
+import (
+	"errors"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/utils"
+	"time"
+)
+
+const (
+	API_KEY_PREFIX        = "rwa_"
+	API_KEY_BYTES         = 32
+	API_KEY_VISIBLE_CHARS = 12
+)
+
+type apiKeyService struct {
+	storage APIKeyStorage
+}
+
+func NewAPIKeyService(storage APIKeyStorage) *apiKeyService {
+	return &apiKeyService{
+		storage: storage,
+	}
+}
+
+// CreateAPIKey returns the key once, only its sha256 hash and a visible prefix are stored
+func (s *apiKeyService) CreateAPIKey(userID string, input *user.CreateAPIKeyDto) (*entity.APIKeyResponse, error) {
+	secret, err := helper.GenerateRandomToken(API_KEY_BYTES)
+	if err != nil {
+		return nil, err
+	}
+	key := API_KEY_PREFIX + secret
+
+	apiKey := &model.APIKey{
+		KeyID:   utils.GenAPIKeyID(),
+		UserID:  userID,
+		Name:    input.APIKey.Name,
+		Prefix:  key[:API_KEY_VISIBLE_CHARS],
+		KeyHash: helper.HashToken(key),
+		Scopes:  input.APIKey.Scopes,
+	}
+	if apiKey.KeyID == "" {
+		return nil, errors.New("could not generate api key id")
+	}
+
+	if input.APIKey.ExpiredInDays > 0 {
+		expiredTime := time.Now().AddDate(0, 0, input.APIKey.ExpiredInDays)
+		apiKey.ExpiredTime = &expiredTime
+	}
+
+	apiKey, err = s.storage.CreateAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewAPIKeyResponse(apiKey, key), nil
+}
+
+func (s *apiKeyService) GetMyAPIKeys(userID string) (*entity.APIKeyListResponse, error) {
+	apiKeys, err := s.storage.GetAPIKeysByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewAPIKeyListResponse(apiKeys), nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(userID, keyID string) error {
+	if keyID == "" {
+		return errors.New("api key is not existed")
+	}
+
+	if _, err := s.storage.GetAPIKeyByID(userID, keyID); err != nil {
+		return errors.New("api key is not existed")
+	}
+
+	return s.storage.DeleteAPIKey(userID, keyID)
+}
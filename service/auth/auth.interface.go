@@ -2,6 +2,8 @@ package auth
 
 import (
 	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
 )
 
 type AuthStorage interface {
@@ -10,6 +12,63 @@ type AuthStorage interface {
 	GetUserByUsernameOrEmail(username, email string) (*model.User, error)
 	GetUserByEmail(email string) (*model.User, error)
 	GetUserByID(id string) (*model.User, error)
-	UpdateUserPassword(query *model.User, password string) (*model.User, error)
-	DeleteToken(token string) error
+	UpdateUserPassword(query *model.User, password string, passwordHistory []string) (*model.User, error)
+	UpdateEmailVerificationSentTime(userID string, sentTime time.Time, resendAfter time.Time) (*model.User, error)
+	UpdateMagicLinkSentTime(userID string, sentTime time.Time, resendAfter time.Time) (*model.User, error)
+	UpdateTwoFactorLastUsedStep(userID string, step int64) (*model.User, error)
+	RemoveTwoFactorRecoveryCode(userID, hashedCode string) (*model.User, error)
+	GetUserByIdentity(provider, subject string) (*model.User, error)
+	AddUserIdentity(userID string, identity *model.UserIdentity) (*model.User, error)
+	RemoveUserIdentity(userID, provider string) (*model.User, error)
+}
+
+type PasswordResetStorage interface {
+	CreatePasswordReset(data *model.PasswordReset) (*model.PasswordReset, error)
+	GetPasswordReset(tokenHash string) (*model.PasswordReset, error)
+	UsePasswordReset(tokenHash string) (*model.PasswordReset, error)
+	DeletePasswordResetsByUserID(userID string) error
+}
+
+type MagicLinkStorage interface {
+	CreateMagicLink(data *model.MagicLink) (*model.MagicLink, error)
+	UseMagicLink(tokenHash string) (*model.MagicLink, error)
+	DeleteMagicLinksByUserID(userID string) error
+}
+
+type WebAuthnCredentialStorage interface {
+	CreateWebAuthnCredential(data *model.WebAuthnCredential) (*model.WebAuthnCredential, error)
+	GetWebAuthnCredentialsByUserID(userID string) ([]*model.WebAuthnCredential, error)
+	GetWebAuthnCredentialByID(credentialID string) (*model.WebAuthnCredential, error)
+	UpdateWebAuthnSignCount(credentialID string, storedCount, signCount int64) (*model.WebAuthnCredential, error)
+	DeleteWebAuthnCredential(userID, credentialID string) error
+}
+
+type WebAuthnChallengeStorage interface {
+	CreateWebAuthnChallenge(data *model.WebAuthnChallenge) (*model.WebAuthnChallenge, error)
+	UseWebAuthnChallenge(challenge string, ceremony enum.WebAuthnCeremonyValue) (*model.WebAuthnChallenge, error)
+}
+
+type LoginAttemptStorage interface {
+	GetLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) (*model.LoginAttempt, error)
+	IncreaseLoginFailure(attemptType enum.LoginAttemptTypeValue, value string, failedTime, expiredTime time.Time) (*model.LoginAttempt, error)
+	LockLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string, lockedUntil, expiredTime time.Time) (*model.LoginAttempt, error)
+	DeleteLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) error
+}
+
+type AuditStorage interface {
+	CreateAuditLog(data *model.AuditLog) (*model.AuditLog, error)
+}
+
+type SessionStorage interface {
+	CreateSession(data *model.Session) (*model.Session, error)
+	GetSessionByID(sessionID string) (*model.Session, error)
+	GetSessionsByUserID(userID string) ([]*model.Session, error)
+	RotateSessionRefreshToken(sessionID, currentTokenHash string, data *model.Session) (*model.Session, error)
+	DeleteSession(userID, sessionID string) error
+	DeleteOtherSessions(userID, currentSessionID string) error
+}
+
+type RevokedTokenStorage interface {
+	RevokeToken(tokenID string, expiredTime time.Time) error
+	IsTokenRevoked(tokenID string) (bool, error)
 }
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"realworld-authentication/config/env"
 	"realworld-authentication/controller"
 	"realworld-authentication/dto/auth"
@@ -12,17 +14,63 @@ import (
 	"realworld-authentication/model/enum"
 	"realworld-authentication/utils"
 	"strings"
+	"time"
+)
+
+const (
+	RESET_PASSWORD_TOKEN_BYTES = 32
+	MAGIC_LINK_TOKEN_BYTES     = 32
 )
 
 type authService struct {
-	storage     AuthStorage
-	fileService controller.FileService
+	storage                   AuthStorage
+	passwordResetStorage      PasswordResetStorage
+	magicLinkStorage          MagicLinkStorage
+	webAuthnCredentialStorage WebAuthnCredentialStorage
+	webAuthnChallengeStorage  WebAuthnChallengeStorage
+	loginAttemptStorage       LoginAttemptStorage
+	auditStorage              AuditStorage
+	sessionStorage            SessionStorage
+	revokedTokenStorage       RevokedTokenStorage
+	fileService               controller.FileService
+	notificationService       controller.NotificationService
+	providerRegistry          *helper.OIDCProviderRegistry
+	breachedPasswordDataset   *helper.BreachedPasswordDataset
+}
+
+// Dependencies lists what the auth service is built from, the storages are required and a nil
+// breached password dataset turns the breach check off
+type Dependencies struct {
+	Storage                   AuthStorage
+	PasswordResetStorage      PasswordResetStorage
+	MagicLinkStorage          MagicLinkStorage
+	WebAuthnCredentialStorage WebAuthnCredentialStorage
+	WebAuthnChallengeStorage  WebAuthnChallengeStorage
+	LoginAttemptStorage       LoginAttemptStorage
+	AuditStorage              AuditStorage
+	SessionStorage            SessionStorage
+	RevokedTokenStorage       RevokedTokenStorage
+	FileService               controller.FileService
+	NotificationService       controller.NotificationService
+	ProviderRegistry          *helper.OIDCProviderRegistry
+	BreachedPasswordDataset   *helper.BreachedPasswordDataset
 }
 
-func NewAuthService(storage AuthStorage, fileService controller.FileService) *authService {
+func NewAuthService(deps *Dependencies) *authService {
 	return &authService{
-		storage:     storage,
-		fileService: fileService,
+		storage:                   deps.Storage,
+		passwordResetStorage:      deps.PasswordResetStorage,
+		magicLinkStorage:          deps.MagicLinkStorage,
+		webAuthnCredentialStorage: deps.WebAuthnCredentialStorage,
+		webAuthnChallengeStorage:  deps.WebAuthnChallengeStorage,
+		loginAttemptStorage:       deps.LoginAttemptStorage,
+		auditStorage:              deps.AuditStorage,
+		sessionStorage:            deps.SessionStorage,
+		revokedTokenStorage:       deps.RevokedTokenStorage,
+		fileService:               deps.FileService,
+		notificationService:       deps.NotificationService,
+		providerRegistry:          deps.ProviderRegistry,
+		breachedPasswordDataset:   deps.BreachedPasswordDataset,
 	}
 }
 
@@ -37,53 +85,267 @@ func (s *authService) SignUp(input *auth.UserSignUpDto) (*entity.UserSignUpRespo
 		return nil, errors.New("username or email is existed")
 	}
 
+	breachCount, err := s.checkPasswordPolicy(input.User.Password, user.Username, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := helper.HashPassword(input.User.Password)
 	if err != nil {
 		return nil, err
 	}
 	user.HashedPassword = hashedPassword
 
+	// account stays pending until the email is verified
+	now := time.Now()
 	user.UserID = utils.GenAccountID()
-	user.Status = enum.UserStatus.Active
+	user.Status = enum.UserStatus.Pending
 	user.Role = enum.UserRole.User
+	user.EmailVerified = &enum.FALSE
+	user.EmailVerificationSentTime = &now
+	user.PasswordChangedAt = &now
 
 	userCreateResp, err := s.storage.CreateUser(user)
 	if err != nil {
 		return nil, err
 	}
 
+	// user can ask for another verification email if this one fails
+	err = s.sendVerificationEmail(userCreateResp)
+	if err != nil {
+		log.Printf("send verification email to %s: %v", userCreateResp.UserID, err)
+	}
+
 	userSignupEntity := entity.NewUserSignupResponse(userCreateResp)
+	userSignupEntity.PasswordBreachCount = breachCount
 	return userSignupEntity, nil
 }
 
+func (s *authService) VerifyEmail(token string) (*entity.UserProfileResponse, error) {
+	tokenDetails, err := helper.ValidateToken(token, env.AppConfig.EmailVerificationKey)
+	if err != nil {
+		return nil, errors.New("verification token is invalid or expired")
+	}
+
+	existUser, err := s.storage.GetUserByID(tokenDetails.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a link sent before the email was changed does not verify the new address
+	if tokenDetails.Email == "" || tokenDetails.Email != existUser.Email {
+		return nil, errors.New("verification token is invalid or expired")
+	}
+
+	if existUser.EmailVerified != nil && *existUser.EmailVerified {
+		return entity.NewUserProfileResponse(existUser), nil
+	}
+
+	updateData := &model.User{
+		EmailVerified: &enum.TRUE,
+	}
+	if existUser.Status == enum.UserStatus.Pending {
+		updateData.Status = enum.UserStatus.Active
+	}
+
+	updateUserResp, err := s.storage.UpdateUser(&model.User{
+		ID: existUser.ID,
+	}, updateData)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewUserProfileResponse(updateUserResp), nil
+}
+
+func (s *authService) ResendVerificationEmail(email string) error {
+	existUser, err := s.storage.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	if existUser.EmailVerified == nil || *existUser.EmailVerified {
+		return nil
+	}
+
+	now := time.Now()
+	_, err = s.storage.UpdateEmailVerificationSentTime(existUser.UserID, now, now.Add(-env.AppConfig.EmailVerificationResendIn*time.Minute))
+	if err != nil {
+		return errors.New("verification email was sent recently, please try again later")
+	}
+
+	return s.sendVerificationEmail(existUser)
+}
+
+func (s *authService) sendVerificationEmail(existUser *model.User) error {
+	verificationToken, err := helper.GenerateEmailVerificationJWT(existUser.UserID, existUser.Email, env.AppConfig.EmailVerificationExpiredIn, env.AppConfig.EmailVerificationKey)
+	if err != nil {
+		return err
+	}
+
+	return s.notificationService.SendVerificationEmail(existUser.Email, fmt.Sprintf(env.AppConfig.EmailVerificationUrl, *verificationToken.Token))
+}
+
 func (s *authService) Login(input *auth.UserLoginDto) (*entity.UserLoginResponse, error) {
+	err := s.checkLoginAttempt(enum.LoginAttemptType.IPAddress, input.Client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+
 	existUserResp, err := s.storage.GetUserByEmail(input.User.Email)
+	if err != nil {
+		_ = s.recordLoginFailure(nil, input.Client.IPAddress)
+		return nil, err
+	}
+
+	err = s.checkLoginAttempt(enum.LoginAttemptType.Account, existUserResp.UserID)
 	if err != nil {
 		return nil, err
 	}
 
 	if !helper.VerifyPassword(existUserResp.HashedPassword, input.User.Password) {
+		err = s.recordLoginFailure(existUserResp, input.Client.IPAddress)
+		if err != nil {
+			return nil, err
+		}
 		return nil, errors.New("password is not matched")
 	}
 
-	accessToken, err := helper.GenerateJWT(existUserResp.UserID, env.AppConfig.AccessTokenExpiredIn, env.AppConfig.AccessTokenKey)
+	s.resetLoginAttempt(existUserResp.UserID)
+	return s.completeLogin(existUserResp, input.Client)
+}
+
+// completeLogin runs the checks shared by every first factor, the caller has already authenticated the user
+func (s *authService) completeLogin(existUserResp *model.User, client auth.SessionClientDto) (*entity.UserLoginResponse, error) {
+	if env.AppConfig.RejectUnverifiedLogin && existUserResp.Status == enum.UserStatus.Pending {
+		return nil, errors.New("email is not verified")
+	}
+
+	twoFactorMethods, err := s.getTwoFactorMethods(existUserResp)
+	if err != nil {
+		return nil, err
+	}
+
+	// user with two factor enabled must exchange the challenge token with a totp code or a security key first
+	if len(twoFactorMethods) > 0 {
+		challengeToken, err := helper.GenerateJWT(existUserResp.UserID, env.AppConfig.TwoFactorChallengeExpiredIn, env.AppConfig.TwoFactorChallengeKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return entity.NewTwoFactorChallengeResponse(existUserResp, *challengeToken.Token, twoFactorMethods), nil
+	}
+
+	return s.finishLogin(existUserResp, client)
+}
+
+// finishLogin runs once every factor is verified, a user who must change the password only gets a password change
+// token that cannot be used as access token
+func (s *authService) finishLogin(existUserResp *model.User, client auth.SessionClientDto) (*entity.UserLoginResponse, error) {
+	if isPasswordChangeRequired(existUserResp) {
+		passwordChangeToken, err := helper.GenerateJWT(existUserResp.UserID, env.AppConfig.PasswordChangeTokenExpiredIn, env.AppConfig.PasswordChangeTokenKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return entity.NewPasswordChangeResponse(existUserResp, *passwordChangeToken.Token), nil
+	}
+
+	return s.issueLoginTokens(existUserResp, client)
+}
+
+// getTwoFactorMethods lists the second factors of the user, a registered security key is always one of them
+func (s *authService) getTwoFactorMethods(existUser *model.User) ([]enum.TwoFactorMethodValue, error) {
+	var methods []enum.TwoFactorMethodValue
+	if existUser.TwoFactorEnabled != nil && *existUser.TwoFactorEnabled {
+		methods = append(methods, enum.TwoFactorMethod.TOTP)
+	}
+
+	credentials, err := s.webAuthnCredentialStorage.GetWebAuthnCredentialsByUserID(existUser.UserID)
 	if err != nil {
 		return nil, err
 	}
-	existUserResp.AccessToken = *accessToken.Token
 
-	refreshToken, err := helper.GenerateJWT(existUserResp.UserID, env.AppConfig.RefreshTokenExpiredIn, env.AppConfig.RefreshTokenKey)
+	if len(credentials) > 0 {
+		methods = append(methods, enum.TwoFactorMethod.WebAuthn)
+	}
+
+	return methods, nil
+}
+
+// LoginWithTwoFactor counts wrong codes like wrong passwords, so the challenge token cannot be used to guess codes
+func (s *authService) LoginWithTwoFactor(input *auth.TwoFactorLoginDto) (*entity.UserLoginResponse, error) {
+	token, err := s.validateTwoFactorChallenge(input.User.ChallengeToken)
 	if err != nil {
 		return nil, err
 	}
-	existUserResp.RefreshToken = *refreshToken.Token
 
-	_, err = s.storage.UpdateUser(&model.User{ID: existUserResp.ID}, existUserResp)
+	err = s.checkLoginAttempt(enum.LoginAttemptType.Account, token.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	return entity.NewUserLoginResponse(existUserResp), nil
+	existUserResp, err := s.storage.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existUserResp.TwoFactorEnabled == nil || !*existUserResp.TwoFactorEnabled {
+		return nil, errors.New("two factor authentication is not enabled")
+	}
+
+	// recovery code is accepted as an alternative second factor
+	if input.User.Code != "" {
+		err = s.verifyTwoFactorCode(existUserResp, input.User.Code)
+	} else {
+		err = s.verifyRecoveryCode(existUserResp, input.User.RecoveryCode)
+	}
+	if err != nil {
+		if lockedErr := s.recordLoginFailure(existUserResp, input.Client.IPAddress); lockedErr != nil {
+			return nil, lockedErr
+		}
+		return nil, err
+	}
+
+	err = s.useTwoFactorChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+
+	s.resetLoginAttempt(existUserResp.UserID)
+	return s.finishLogin(existUserResp, input.Client)
+}
+
+// validateTwoFactorChallenge also rejects a challenge token which has already completed a login
+func (s *authService) validateTwoFactorChallenge(challengeToken string) (*helper.TokenDetails, error) {
+	token, err := helper.ValidateToken(challengeToken, env.AppConfig.TwoFactorChallengeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.TokenID == "" {
+		return nil, errors.New("challenge token is invalid")
+	}
+
+	revoked, err := s.revokedTokenStorage.IsTokenRevoked(token.TokenID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("challenge token is already used")
+	}
+
+	return token, nil
+}
+
+// useTwoFactorChallenge revokes the jti of the challenge token, one challenge only ever completes one login
+func (s *authService) useTwoFactorChallenge(token *helper.TokenDetails) error {
+	expiredTime := time.Now().Add(env.AppConfig.TwoFactorChallengeExpiredIn * time.Minute)
+	if token.ExpiredIn != nil {
+		expiredTime = time.Unix(*token.ExpiredIn, 0)
+	}
+
+	return s.revokedTokenStorage.RevokeToken(token.TokenID, expiredTime)
 }
 
 func (s *authService) RefreshToken(input *auth.RefreshTokenRequestDto) (*entity.TokenResponse, error) {
@@ -97,67 +359,134 @@ func (s *authService) RefreshToken(input *auth.RefreshTokenRequestDto) (*entity.
 		return nil, err
 	}
 
-	accessToken, err := helper.GenerateJWT(token.UserID, env.AppConfig.AccessTokenExpiredIn, env.AppConfig.AccessTokenKey)
+	session, err := s.sessionStorage.GetSessionByID(token.SessionID)
+	if err != nil || session.UserID != token.UserID {
+		return nil, errors.New("refresh token is revoked")
+	}
+
+	// a valid token of the session which is not the latest one was already rotated
+	if helper.HashToken(input.RefreshToken) != session.RefreshTokenHash {
+		return nil, s.revokeReusedSession(session, input.Client.IPAddress)
+	}
+
+	accessToken, err := helper.GenerateAccessJWT(token.UserID, session.SessionID, env.AppConfig.AccessTokenExpiredIn)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := helper.GenerateJWT(token.UserID, env.AppConfig.RefreshTokenExpiredIn, env.AppConfig.RefreshTokenKey)
+	refreshToken, err := helper.GenerateSessionJWT(token.UserID, session.SessionID, env.AppConfig.RefreshTokenExpiredIn, env.AppConfig.RefreshTokenKey)
 	if err != nil {
 		return nil, err
 	}
 
-	// update new refresh token in db for the user
-	_, err = s.storage.UpdateUser(&model.User{
-		UserID: token.UserID,
-	}, &model.User{
-		RefreshToken: *refreshToken.Token,
+	// update new refresh token of the session, it fails if a concurrent request already rotated the token
+	now := time.Now()
+	expiredTime := time.Unix(*refreshToken.ExpiredIn, 0)
+	_, err = s.sessionStorage.RotateSessionRefreshToken(session.SessionID, session.RefreshTokenHash, &model.Session{
+		RefreshTokenHash: helper.HashToken(*refreshToken.Token),
+		IPAddress:        input.Client.IPAddress,
+		UserAgent:        input.Client.UserAgent,
+		LastUsedTime:     &now,
+		ExpiredTime:      &expiredTime,
 	})
 	if err != nil {
-		return nil, err
+		return nil, s.revokeReusedSession(session, input.Client.IPAddress)
 	}
 
 	return entity.NewTokenResp(*accessToken.Token, *refreshToken.Token), nil
 }
 
-func (s *authService) LoginWithGoogle(input *auth.GoogleLoginDto) (*entity.GoogleOauthTokenResponse, error) {
-	tokenResp, err := helper.GetGoogleOauthToken(input.AuthorizationCode)
+func (s *authService) LoginWithGoogle(input *auth.GoogleLoginDto) (*entity.SocialLoginResponse, error) {
+	return s.LoginWithProvider(&auth.ProviderLoginDto{
+		Provider:          helper.GOOGLE_PROVIDER,
+		AuthorizationCode: input.AuthorizationCode,
+		State:             input.State,
+		StateToken:        input.StateToken,
+		Client:            input.Client,
+	})
+}
+
+// StartProviderLogin returns the provider authorization url, the state token has to be stored by the browser
+// and sent back with the callback
+func (s *authService) StartProviderLogin(input *auth.ProviderLoginStartDto) (*entity.ProviderLoginStartResponse, error) {
+	provider := strings.ToLower(input.Provider)
+
+	returnPath := input.ReturnPath
+	if returnPath == "" {
+		returnPath = "/"
+	}
+
+	if !helper.IsAllowedReturnPath(returnPath) {
+		return nil, errors.New("return path is not allowed")
+	}
+
+	oauthState, stateToken, err := helper.GenerateOAuthState(provider, returnPath, input.LinkUserID)
 	if err != nil {
 		return nil, err
 	}
 
-	googleUserInfo, err := helper.GetGoogleUserInfo(tokenResp.AccessToken, tokenResp.TokenID)
+	var authorizationUrl string
+	if provider == helper.GITHUB_PROVIDER {
+		authorizationUrl, err = helper.GetGithubAuthCodeURL(oauthState.State)
+	} else {
+		oidcProvider, providerErr := s.providerRegistry.GetProvider(provider)
+		if providerErr != nil {
+			return nil, providerErr
+		}
+
+		authorizationUrl, err = oidcProvider.AuthCodeURL(oauthState.State, oauthState.Nonce, helper.ComputePKCEChallenge(oauthState.CodeVerifier))
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		userEmail = strings.ToLower(googleUserInfo.Email)
-	)
+	return &entity.ProviderLoginStartResponse{
+		AuthorizationUrl: authorizationUrl,
+		StateToken:       stateToken,
+	}, nil
+}
 
-	userResp, err := s.storage.GetUserByEmail(userEmail)
+// LoginWithProvider exchanges the code with github or a configured openid connect provider once the state
+// matches the one issued by StartProviderLogin, the user is read from the verified id token. A state started by
+// StartLinkIdentity links the identity to its user instead of logging in
+func (s *authService) LoginWithProvider(input *auth.ProviderLoginDto) (*entity.SocialLoginResponse, error) {
+	oauthState, err := helper.ValidateOAuthState(input.StateToken, input.State, input.Provider)
 	if err != nil {
-		userResp = &model.User{
-			UserID:   googleUserInfo.ID,
-			Email:    userEmail,
-			Username: googleUserInfo.Name,
-			Provider: enum.ProviderName.Google,
-			Role:     enum.UserRole.User,
-		}
+		return nil, err
+	}
 
-		_, err := s.storage.CreateUser(userResp)
-		if err != nil {
-			return nil, err
-		}
+	identity, err := s.resolveProviderIdentity(input.Provider, input.AuthorizationCode, oauthState.CodeVerifier, oauthState.Nonce)
+	if err != nil {
+		return nil, err
 	}
 
-	accessToken, err := helper.GenerateJWT(userResp.UserID, env.AppConfig.AccessTokenExpiredIn, env.AppConfig.AccessTokenKey)
+	var socialLoginResp *entity.SocialLoginResponse
+	if oauthState.LinkUserID != "" {
+		socialLoginResp, err = s.linkIdentity(oauthState.LinkUserID, identity)
+	} else {
+		socialLoginResp, err = s.loginWithIdentity(identity, input.Client)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	userResp.AccessToken = *accessToken.Token
-	return entity.NewGoogleOauthTokenResp(userResp.AccessToken), nil
+	// the allow list may have changed since the login started
+	socialLoginResp.ReturnPath = "/"
+	if helper.IsAllowedReturnPath(oauthState.ReturnPath) {
+		socialLoginResp.ReturnPath = oauthState.ReturnPath
+	}
+
+	return socialLoginResp, nil
+}
+
+func (s *authService) LoginWithGithub(input *auth.GithubLoginDto) (*entity.SocialLoginResponse, error) {
+	return s.LoginWithProvider(&auth.ProviderLoginDto{
+		Provider:          helper.GITHUB_PROVIDER,
+		AuthorizationCode: input.AuthorizationCode,
+		State:             input.State,
+		StateToken:        input.StateToken,
+		Client:            input.Client,
+	})
 }
 
 func (s *authService) GetUserProfileByID(userID string) (*entity.UserProfileResponse, error) {
@@ -175,9 +504,17 @@ func (s *authService) UpdateUserProfile(userID string, input *user.UserProfileUp
 		return nil, err
 	}
 
+	// a new email is not verified, the account is pending again until the user opens the link sent to it
+	now := time.Now()
+	emailChanged := input.User.Email != "" && input.User.Email != existUser.Email
 	updateData := &model.User{}
-	if input.User.Email != "" {
+	if emailChanged {
 		updateData.Email = input.User.Email
+		updateData.EmailVerified = &enum.FALSE
+		updateData.EmailVerificationSentTime = &now
+		if existUser.Status == enum.UserStatus.Active {
+			updateData.Status = enum.UserStatus.Pending
+		}
 	}
 	if input.User.Username != "" {
 		updateData.Username = input.User.Username
@@ -196,6 +533,14 @@ func (s *authService) UpdateUserProfile(userID string, input *user.UserProfileUp
 		return nil, err
 	}
 
+	if emailChanged {
+		// user can ask for another verification email if this one fails
+		err = s.sendVerificationEmail(updateUserResp)
+		if err != nil {
+			log.Printf("send verification email to %s: %v", updateUserResp.UserID, err)
+		}
+	}
+
 	return entity.NewUserProfileResponse(updateUserResp), nil
 }
 
@@ -209,6 +554,16 @@ func (s *authService) ResetPassword(userID string, input *user.UserResetPassword
 		return nil, errors.New("current password is not matched")
 	}
 
+	breachCount, err := s.checkPasswordPolicy(input.User.NewPassword, existUser.Username, existUser.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHistory, err := s.checkPasswordHistory(existUser, input.User.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := helper.HashPassword(input.User.NewPassword)
 	if err != nil {
 		return nil, err
@@ -216,43 +571,298 @@ func (s *authService) ResetPassword(userID string, input *user.UserResetPassword
 
 	updateUserPassword, err := s.storage.UpdateUserPassword(&model.User{
 		ID: existUser.ID,
-	}, hashedPassword)
+	}, hashedPassword, passwordHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewUserPasswordResponse(updateUserPassword, breachCount), nil
+}
+
+func (s *authService) SetupTwoFactor(userID string) (*entity.TwoFactorSetupResponse, error) {
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existUser.TwoFactorEnabled != nil && *existUser.TwoFactorEnabled {
+		return nil, errors.New("two factor authentication is already enabled")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSecret, err := helper.EncryptString(secret, env.AppConfig.TwoFactorEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// secret is pending until the user confirms it with a valid code
+	_, err = s.storage.UpdateUser(&model.User{
+		ID: existUser.ID,
+	}, &model.User{
+		TwoFactorSecret:  encryptedSecret,
+		TwoFactorEnabled: &enum.FALSE,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	provisioningURI := helper.BuildTOTPProvisioningURI(env.AppConfig.TwoFactorIssuer, existUser.Email, secret)
+	return entity.NewTwoFactorSetupResponse(secret, provisioningURI), nil
+}
+
+func (s *authService) ConfirmTwoFactor(userID string, input *user.TwoFactorConfirmDto) (*entity.TwoFactorStatusResponse, error) {
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existUser.TwoFactorEnabled != nil && *existUser.TwoFactorEnabled {
+		return nil, errors.New("two factor authentication is already enabled")
+	}
+
+	if existUser.TwoFactorSecret == "" {
+		return nil, errors.New("two factor authentication is not set up")
+	}
+
+	err = s.verifyTwoFactorCode(existUser, input.User.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes(env.AppConfig.TwoFactorRecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.storage.UpdateUser(&model.User{
+		ID: existUser.ID,
+	}, &model.User{
+		TwoFactorEnabled:       &enum.TRUE,
+		TwoFactorRecoveryCodes: hashedRecoveryCodes,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return entity.NewUserPasswordResponse(updateUserPassword), nil
+	return entity.NewTwoFactorEnabledResponse(recoveryCodes), nil
 }
 
-func (s *authService) Logout(userID string) error {
+func (s *authService) RegenerateRecoveryCodes(userID string) (*entity.TwoFactorStatusResponse, error) {
 	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existUser.TwoFactorEnabled == nil || !*existUser.TwoFactorEnabled {
+		return nil, errors.New("two factor authentication is not enabled")
+	}
+
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes(env.AppConfig.TwoFactorRecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// replace the whole set, so every previous code is invalidated
+	_, err = s.storage.UpdateUser(&model.User{
+		ID: existUser.ID,
+	}, &model.User{
+		TwoFactorRecoveryCodes: hashedRecoveryCodes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewTwoFactorEnabledResponse(recoveryCodes), nil
+}
+
+func (s *authService) verifyTwoFactorCode(existUser *model.User, code string) error {
+	secret, err := helper.DecryptString(existUser.TwoFactorSecret, env.AppConfig.TwoFactorEncryptionKey)
 	if err != nil {
 		return err
 	}
 
-	// revoke user refresh token in db
-	return s.storage.DeleteToken(existUser.RefreshToken)
+	step, ok := helper.ValidateTOTPCode(secret, code, time.Now(), env.AppConfig.TwoFactorSkew)
+	if !ok {
+		return errors.New("two factor code is invalid")
+	}
+
+	// reject code of a step which is already used
+	_, err = s.storage.UpdateTwoFactorLastUsedStep(existUser.UserID, step)
+	if err != nil {
+		return errors.New("two factor code is already used")
+	}
+
+	return nil
 }
 
-func (s *authService) ForgetPassword(email string) (*entity.UserPasswordResponse, error) {
+func (s *authService) verifyRecoveryCode(existUser *model.User, code string) error {
+	normalizedCode := normalizeRecoveryCode(code)
+
+	for _, hashedCode := range existUser.TwoFactorRecoveryCodes {
+		if !helper.VerifyPassword(hashedCode, normalizedCode) {
+			continue
+		}
+
+		// recovery code is single use
+		_, err := s.storage.RemoveTwoFactorRecoveryCode(existUser.UserID, hashedCode)
+		if err != nil {
+			return errors.New("recovery code is already used")
+		}
+
+		return nil
+	}
+
+	return errors.New("recovery code is invalid")
+}
+
+func generateRecoveryCodes(count int) ([]string, []string, error) {
+	recoveryCodes := make([]string, 0, count)
+	hashedRecoveryCodes := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		code := utils.GenRecoveryCode()
+		if code == "" {
+			return nil, nil, errors.New("could not generate recovery code")
+		}
+
+		hashedCode, err := helper.HashPassword(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, code)
+		hashedRecoveryCodes = append(hashedRecoveryCodes, hashedCode)
+	}
+
+	return recoveryCodes, hashedRecoveryCodes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func (s *authService) Logout(input *auth.LogoutDto) error {
+	_, err := s.storage.GetUserByID(input.UserID)
+	if err != nil {
+		return err
+	}
+
+	// access token stays in the revocation list until it expires
+	if input.TokenID != "" && input.ExpiredIn != nil {
+		err = s.revokedTokenStorage.RevokeToken(input.TokenID, time.Unix(*input.ExpiredIn, 0))
+		if err != nil {
+			return err
+		}
+	}
+
+	// token issued before sessions existed has no session to revoke
+	if input.SessionID == "" {
+		return nil
+	}
+
+	return s.revokeSession(input.UserID, input.SessionID)
+}
+
+// ForgetPassword never reveals whether the email is registered, it answers before the reset link is created and
+// sent so the response time does not tell either, failures are only logged
+func (s *authService) ForgetPassword(email string) error {
 	existUser, err := s.storage.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	go s.sendPasswordReset(existUser)
+	return nil
+}
+
+func (s *authService) sendPasswordReset(existUser *model.User) {
+	resetToken, err := helper.GenerateRandomToken(RESET_PASSWORD_TOKEN_BYTES)
+	if err != nil {
+		log.Printf("create password reset token for %s: %v", existUser.UserID, err)
+		return
+	}
+
+	expiredTime := time.Now().Add(env.AppConfig.ResetPasswordTokenExpiredIn * time.Minute)
+	_, err = s.passwordResetStorage.CreatePasswordReset(&model.PasswordReset{
+		UserID:      existUser.UserID,
+		TokenHash:   helper.HashToken(resetToken),
+		ExpiredTime: &expiredTime,
+	})
+	if err != nil {
+		log.Printf("create password reset for %s: %v", existUser.UserID, err)
+		return
+	}
+
+	// send email with the reset link
+	err = s.notificationService.SendResetPasswordEmail(existUser.Email, fmt.Sprintf(env.AppConfig.ResetPasswordUrl, resetToken))
+	if err != nil {
+		log.Printf("send reset password email to %s: %v", existUser.UserID, err)
+	}
+}
+
+func (s *authService) ResetPasswordWithToken(input *auth.ResetPasswordDto) (*entity.UserPasswordResponse, error) {
+	tokenHash := helper.HashToken(input.User.Token)
+
+	// the token is only consumed once the new password is accepted, so the user can retry with the same link
+	passwordReset, err := s.passwordResetStorage.GetPasswordReset(tokenHash)
+	if err != nil {
+		return nil, errors.New("reset password token is invalid or expired")
+	}
+
+	existUser, err := s.storage.GetUserByID(passwordReset.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	breachCount, err := s.checkPasswordPolicy(input.User.NewPassword, existUser.Username, existUser.Email)
 	if err != nil {
 		return nil, err
 	}
 
-	// send email to get new password
-	randomToken := utils.RandomString(utils.PASSWORD_LENGTH)
-	err := client.NotificationService.SendEmail(email, randomToken)
+	passwordHistory, err := s.checkPasswordHistory(existUser, input.User.NewPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	updateUserResp, err = s.storage.UpdateUserPassword(&model.User{
+	_, err = s.passwordResetStorage.UsePasswordReset(tokenHash)
+	if err != nil {
+		return nil, errors.New("reset password token is invalid or expired")
+	}
+
+	hashedPassword, err := helper.HashPassword(input.User.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	updateUserPassword, err := s.storage.UpdateUserPassword(&model.User{
 		ID: existUser.ID,
-	}, randomToken)
+	}, hashedPassword, passwordHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	// other pending reset links of the user are no longer valid
+	err = s.passwordResetStorage.DeletePasswordResetsByUserID(existUser.UserID)
+	if err != nil {
+		log.Printf("delete password resets of %s: %v", existUser.UserID, err)
+	}
+
+	// whoever knew the old password may still be logged in, every session has to login with the new one
+	sessions, err := s.sessionStorage.GetSessionsByUserID(existUser.UserID)
 	if err != nil {
 		return nil, err
 	}
+	for _, session := range sessions {
+		err = s.revokeSession(existUser.UserID, session.SessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return entity.NewUserPasswordResponse(updateUserResp), nil
+	return entity.NewUserPasswordResponse(updateUserPassword, breachCount), nil
 }
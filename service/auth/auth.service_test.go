@@ -0,0 +1,697 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"realworld-authentication/config/env"
+	"realworld-authentication/controller"
+	"realworld-authentication/dto/auth"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+access_token_key: test-access-token-key
+access_token_expired_in: 15
+refresh_token_key: test-refresh-token-key
+refresh_token_expired_in: 60
+two_factor_encryption_key: test-two-factor-encryption-key
+two_factor_challenge_key: test-two-factor-challenge-key
+two_factor_challenge_expired_in: 5
+two_factor_skew: 1
+password_change_token_key: test-password-change-token-key
+email_verification_key: test-email-verification-key
+email_verification_url: http://localhost/verify?token=%s
+oauth_state_key: test-oauth-state-key
+github_oauth_client_id: github-client-id
+github_oauth_secret: github-client-secret
+`
+
+type fakeAuthStorage struct {
+	AuthStorage
+	mu    sync.Mutex
+	users map[string]*model.User
+}
+
+func (f *fakeAuthStorage) GetUserByID(id string) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[id]
+	if !ok {
+		return nil, errors.New("document is not existed")
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeAuthStorage) UpdateTwoFactorLastUsedStep(userID string, step int64) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[userID]
+	if !ok || user.TwoFactorLastUsedStep >= step {
+		return nil, errors.New("document is not existed")
+	}
+
+	user.TwoFactorLastUsedStep = step
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeAuthStorage) UpdateUser(query, data *model.User) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, user := range f.users {
+		if user.ID != query.ID {
+			continue
+		}
+		if data.Email != "" {
+			user.Email = data.Email
+		}
+		if data.EmailVerified != nil {
+			user.EmailVerified = data.EmailVerified
+		}
+		if data.Status != "" {
+			user.Status = data.Status
+		}
+		if data.Username != "" {
+			user.Username = data.Username
+		}
+		copied := *user
+		return &copied, nil
+	}
+
+	return nil, errors.New("document is not existed")
+}
+
+func (f *fakeAuthStorage) UpdateUserPassword(query *model.User, password string, passwordHistory []string) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, user := range f.users {
+		if user.ID != query.ID {
+			continue
+		}
+		user.HashedPassword = password
+		user.PasswordHistory = passwordHistory
+		copied := *user
+		return &copied, nil
+	}
+
+	return nil, errors.New("document is not existed")
+}
+
+func (f *fakeAuthStorage) GetUserByIdentity(provider, subject string) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, user := range f.users {
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				copied := *user
+				return &copied, nil
+			}
+		}
+	}
+
+	return nil, errors.New("document is not existed")
+}
+
+func (f *fakeAuthStorage) AddUserIdentity(userID string, identity *model.UserIdentity) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[userID]
+	if !ok {
+		return nil, errors.New("document is not existed")
+	}
+
+	user.Identities = append(user.Identities, identity)
+	copied := *user
+	return &copied, nil
+}
+
+type fakeAuditStorage struct{}
+
+func (f *fakeAuditStorage) CreateAuditLog(data *model.AuditLog) (*model.AuditLog, error) {
+	return data, nil
+}
+
+type fakeNotificationService struct {
+	controller.NotificationService
+	verificationEmails []string
+}
+
+func (f *fakeNotificationService) SendVerificationEmail(email, verifyLink string) error {
+	f.verificationEmails = append(f.verificationEmails, email)
+	return nil
+}
+
+type fakeRevokedTokenStorage struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func (f *fakeRevokedTokenStorage) RevokeToken(tokenID string, expiredTime time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.revoked[tokenID] = expiredTime
+	return nil
+}
+
+func (f *fakeRevokedTokenStorage) IsTokenRevoked(tokenID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.revoked[tokenID]
+	return ok, nil
+}
+
+type fakeLoginAttemptStorage struct {
+	LoginAttemptStorage
+	mu       sync.Mutex
+	attempts map[string]*model.LoginAttempt
+}
+
+func (f *fakeLoginAttemptStorage) GetLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) (*model.LoginAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	attempt, ok := f.attempts[string(attemptType)+value]
+	if !ok {
+		return nil, errors.New("document is not existed")
+	}
+
+	return attempt, nil
+}
+
+func (f *fakeLoginAttemptStorage) IncreaseLoginFailure(attemptType enum.LoginAttemptTypeValue, value string, failedTime, expiredTime time.Time) (*model.LoginAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	attempt, ok := f.attempts[string(attemptType)+value]
+	if !ok {
+		attempt = &model.LoginAttempt{Type: attemptType, Value: value}
+		f.attempts[string(attemptType)+value] = attempt
+	}
+	attempt.FailedCount++
+	attempt.LastFailedTime = &failedTime
+	attempt.ExpiredTime = &expiredTime
+
+	return attempt, nil
+}
+
+func (f *fakeLoginAttemptStorage) DeleteLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.attempts, string(attemptType)+value)
+	return nil
+}
+
+type fakeMagicLinkStorage struct {
+	MagicLinkStorage
+	links map[string]*model.MagicLink
+}
+
+func (f *fakeMagicLinkStorage) UseMagicLink(tokenHash string) (*model.MagicLink, error) {
+	link, ok := f.links[tokenHash]
+	if !ok {
+		return nil, errors.New("document is not existed")
+	}
+
+	delete(f.links, tokenHash)
+	return link, nil
+}
+
+func (f *fakeMagicLinkStorage) DeleteMagicLinksByUserID(userID string) error {
+	return nil
+}
+
+type fakePasswordResetStorage struct {
+	PasswordResetStorage
+	resets map[string]*model.PasswordReset
+}
+
+func (f *fakePasswordResetStorage) GetPasswordReset(tokenHash string) (*model.PasswordReset, error) {
+	reset, ok := f.resets[tokenHash]
+	if !ok {
+		return nil, errors.New("document is not existed")
+	}
+
+	return reset, nil
+}
+
+func (f *fakePasswordResetStorage) UsePasswordReset(tokenHash string) (*model.PasswordReset, error) {
+	reset, ok := f.resets[tokenHash]
+	if !ok {
+		return nil, errors.New("document is not existed")
+	}
+
+	delete(f.resets, tokenHash)
+	return reset, nil
+}
+
+func (f *fakePasswordResetStorage) DeletePasswordResetsByUserID(userID string) error {
+	return nil
+}
+
+type fakeSessionStorage struct {
+	SessionStorage
+	mu       sync.Mutex
+	sessions []*model.Session
+}
+
+func (f *fakeSessionStorage) CreateSession(data *model.Session) (*model.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sessions = append(f.sessions, data)
+	return data, nil
+}
+
+func (f *fakeSessionStorage) GetSessionsByUserID(userID string) ([]*model.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions := []*model.Session{}
+	for _, session := range f.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (f *fakeSessionStorage) DeleteSession(userID, sessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions := []*model.Session{}
+	for _, session := range f.sessions {
+		if session.UserID != userID || session.SessionID != sessionID {
+			sessions = append(sessions, session)
+		}
+	}
+	f.sessions = sessions
+	return nil
+}
+
+type fakeWebAuthnCredentialStorage struct {
+	WebAuthnCredentialStorage
+}
+
+func (f *fakeWebAuthnCredentialStorage) GetWebAuthnCredentialsByUserID(userID string) ([]*model.WebAuthnCredential, error) {
+	return nil, nil
+}
+
+func loadTestConfig(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.LoadConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	helper.AccessTokenKeySet.SetKeys(helper.NewHMACSigningKey(env.AppConfig.AccessTokenKey))
+}
+
+// newTwoFactorTestService returns a service holding one user with totp enabled and the plain totp secret
+func newTwoFactorTestService(t *testing.T) (*authService, *model.User, string) {
+	t.Helper()
+	loadTestConfig(t)
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedSecret, err := helper.EncryptString(secret, env.AppConfig.TwoFactorEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &model.User{
+		UserID:           "user-1",
+		Email:            "user@example.com",
+		Username:         "user1",
+		Status:           enum.UserStatus.Active,
+		TwoFactorEnabled: &enum.TRUE,
+		TwoFactorSecret:  encryptedSecret,
+	}
+
+	service := NewAuthService(&Dependencies{
+		Storage:                   &fakeAuthStorage{users: map[string]*model.User{user.UserID: user}},
+		WebAuthnCredentialStorage: &fakeWebAuthnCredentialStorage{},
+		LoginAttemptStorage:       &fakeLoginAttemptStorage{attempts: map[string]*model.LoginAttempt{}},
+		SessionStorage:            &fakeSessionStorage{},
+		RevokedTokenStorage:       &fakeRevokedTokenStorage{revoked: map[string]time.Time{}},
+	})
+
+	return service, user, secret
+}
+
+func newTwoFactorLoginDto(t *testing.T, service *authService, user *model.User, code string) *auth.TwoFactorLoginDto {
+	t.Helper()
+
+	loginResp, err := service.completeLogin(user, auth.SessionClientDto{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loginResp.User.ChallengeToken == "" {
+		t.Fatal("completeLogin() did not ask for the second factor")
+	}
+
+	input := &auth.TwoFactorLoginDto{}
+	input.User.ChallengeToken = loginResp.User.ChallengeToken
+	input.User.Code = code
+	return input
+}
+
+func TestLoginWithTwoFactorRejectsReplayedCode(t *testing.T) {
+	service, user, secret := newTwoFactorTestService(t)
+
+	code, err := helper.GenerateTOTPCode(secret, helper.GetTOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loginResp, err := service.LoginWithTwoFactor(newTwoFactorLoginDto(t, service, user, code))
+	if err != nil {
+		t.Fatalf("LoginWithTwoFactor() first use: %v", err)
+	}
+	if loginResp.User.AccessToken == "" {
+		t.Fatal("LoginWithTwoFactor() did not issue an access token")
+	}
+
+	// a fresh challenge does not make the same code usable again
+	_, err = service.LoginWithTwoFactor(newTwoFactorLoginDto(t, service, user, code))
+	if err == nil || err.Error() != "two factor code is already used" {
+		t.Fatalf("LoginWithTwoFactor() with a replayed code: err = %v", err)
+	}
+}
+
+func TestLoginWithTwoFactorRejectsUsedChallenge(t *testing.T) {
+	service, user, secret := newTwoFactorTestService(t)
+
+	step := helper.GetTOTPStep(time.Now())
+	code, err := helper.GenerateTOTPCode(secret, step-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := newTwoFactorLoginDto(t, service, user, code)
+	if _, err = service.LoginWithTwoFactor(input); err != nil {
+		t.Fatalf("LoginWithTwoFactor() first use: %v", err)
+	}
+
+	input.User.Code, err = helper.GenerateTOTPCode(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.LoginWithTwoFactor(input)
+	if err == nil || err.Error() != "challenge token is already used" {
+		t.Fatalf("LoginWithTwoFactor() with a used challenge: err = %v", err)
+	}
+}
+
+func TestLoginWithTwoFactorCountsFailedCodes(t *testing.T) {
+	service, user, secret := newTwoFactorTestService(t)
+
+	code, err := helper.GenerateTOTPCode(secret, helper.GetTOTPStep(time.Now())+5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := newTwoFactorLoginDto(t, service, user, code)
+	if _, err = service.LoginWithTwoFactor(input); err == nil {
+		t.Fatal("LoginWithTwoFactor() accepted a code outside the window")
+	}
+
+	attempt, err := service.loginAttemptStorage.GetLoginAttempt(enum.LoginAttemptType.Account, user.UserID)
+	if err != nil || attempt.FailedCount != 1 {
+		t.Fatalf("failed code was not counted: attempt = %+v, err = %v", attempt, err)
+	}
+
+	// the back-off applies to the challenge token as it does to the password
+	var apiErr *helper.APIError
+	_, err = service.LoginWithTwoFactor(input)
+	if !errors.As(err, &apiErr) || apiErr.Code != enum.ErrorCodeTooManyRequests.Login {
+		t.Fatalf("LoginWithTwoFactor() during back-off: err = %v", err)
+	}
+}
+
+func TestLoginWithMagicLinkRespectsAccountLock(t *testing.T) {
+	service, user, _ := newTwoFactorTestService(t)
+	env.AppConfig.MagicLinkEnabled = true
+
+	service.magicLinkStorage = &fakeMagicLinkStorage{links: map[string]*model.MagicLink{
+		helper.HashToken("magic-token"): {UserID: user.UserID, Email: user.Email},
+	}}
+
+	lockedUntil := time.Now().Add(time.Hour)
+	_, err := service.loginAttemptStorage.IncreaseLoginFailure(enum.LoginAttemptType.Account, user.UserID, time.Now(), lockedUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempt, _ := service.loginAttemptStorage.GetLoginAttempt(enum.LoginAttemptType.Account, user.UserID)
+	attempt.LockedUntil = &lockedUntil
+
+	var apiErr *helper.APIError
+	_, err = service.LoginWithMagicLink(&auth.MagicLinkLoginDto{Token: "magic-token"})
+	if !errors.As(err, &apiErr) || apiErr.Code != enum.ErrorCodeLocked.Account {
+		t.Fatalf("LoginWithMagicLink() on a locked account: err = %v", err)
+	}
+}
+
+func TestUpdateUserProfileEmailChangeNeedsVerification(t *testing.T) {
+	service, existUser, _ := newTwoFactorTestService(t)
+	existUser.EmailVerified = &enum.TRUE
+	notificationService := &fakeNotificationService{}
+	service.notificationService = notificationService
+
+	input := &user.UserProfileUpdateDto{}
+	input.User.Username = existUser.Username
+	input.User.Email = existUser.Email
+	if _, err := service.UpdateUserProfile(existUser.UserID, input); err != nil {
+		t.Fatal(err)
+	}
+	if len(notificationService.verificationEmails) != 0 {
+		t.Fatal("UpdateUserProfile() sent a verification email without an email change")
+	}
+
+	input.User.Email = "new@example.com"
+	if _, err := service.UpdateUserProfile(existUser.UserID, input); err != nil {
+		t.Fatal(err)
+	}
+
+	updatedUser, _ := service.storage.GetUserByID(existUser.UserID)
+	if *updatedUser.EmailVerified || updatedUser.Status != enum.UserStatus.Pending {
+		t.Fatalf("UpdateUserProfile() kept the account verified: verified = %v, status = %s", *updatedUser.EmailVerified, updatedUser.Status)
+	}
+	if len(notificationService.verificationEmails) != 1 || notificationService.verificationEmails[0] != "new@example.com" {
+		t.Fatalf("UpdateUserProfile() verification emails = %v", notificationService.verificationEmails)
+	}
+}
+
+func TestVerifyEmailRejectsLinkForPreviousEmail(t *testing.T) {
+	service, existUser, _ := newTwoFactorTestService(t)
+	existUser.EmailVerified = &enum.FALSE
+	existUser.Status = enum.UserStatus.Pending
+
+	oldToken, err := helper.GenerateEmailVerificationJWT(existUser.UserID, existUser.Email, env.AppConfig.EmailVerificationExpiredIn, env.AppConfig.EmailVerificationKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existUser.Email = "new@example.com"
+
+	if _, err = service.VerifyEmail(*oldToken.Token); err == nil {
+		t.Fatal("VerifyEmail() accepted a link sent to the previous email")
+	}
+
+	newToken, err := helper.GenerateEmailVerificationJWT(existUser.UserID, existUser.Email, env.AppConfig.EmailVerificationExpiredIn, env.AppConfig.EmailVerificationKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := service.VerifyEmail(*newToken.Token)
+	if err != nil {
+		t.Fatalf("VerifyEmail() with a link for the current email: %v", err)
+	}
+	if profile.User.Email != "new@example.com" {
+		t.Fatalf("VerifyEmail() verified %s", profile.User.Email)
+	}
+}
+
+// startGithubStub answers the github token and user endpoints with the account 583231
+func startGithubStub(t *testing.T) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			fmt.Fprint(w, `{"access_token":"gho_test","token_type":"bearer"}`)
+		case "/api/user":
+			fmt.Fprint(w, `{"id":583231,"login":"octocat","name":"The Octocat"}`)
+		case "/api/user/emails":
+			fmt.Fprint(w, `[{"email":"octocat@example.com","primary":true,"verified":true}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	env.AppConfig.GithubOauthBaseUrl = server.URL + "/"
+	env.AppConfig.GithubApiBaseUrl = server.URL + "/api"
+}
+
+func TestLinkIdentityGoesThroughProviderState(t *testing.T) {
+	service, existUser, _ := newTwoFactorTestService(t)
+	service.auditStorage = &fakeAuditStorage{}
+	startGithubStub(t)
+
+	startResp, err := service.StartLinkIdentity(existUser.UserID, &user.LinkIdentityDto{Provider: helper.GITHUB_PROVIDER})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorizationUrl, err := url.Parse(startResp.AuthorizationUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := authorizationUrl.Query().Get("state")
+
+	// the code alone, without the state cookie of the browser that started the link, is refused
+	_, err = service.LoginWithProvider(&auth.ProviderLoginDto{Provider: helper.GITHUB_PROVIDER, AuthorizationCode: "code", State: state})
+	if err == nil {
+		t.Fatal("LoginWithProvider() linked without the state cookie")
+	}
+
+	linkResp, err := service.LoginWithProvider(&auth.ProviderLoginDto{
+		Provider:          helper.GITHUB_PROVIDER,
+		AuthorizationCode: "code",
+		State:             state,
+		StateToken:        startResp.StateToken,
+	})
+	if err != nil {
+		t.Fatalf("LoginWithProvider() for a link: %v", err)
+	}
+	if linkResp.LinkedProvider != helper.GITHUB_PROVIDER || linkResp.Token.AccessToken != "" {
+		t.Fatalf("LoginWithProvider() for a link = %+v", linkResp)
+	}
+
+	linkedUser, err := service.storage.GetUserByIdentity(helper.GITHUB_PROVIDER, "583231")
+	if err != nil || linkedUser.UserID != existUser.UserID {
+		t.Fatalf("identity is not linked to %s: user = %+v, err = %v", existUser.UserID, linkedUser, err)
+	}
+}
+
+func TestLoginWithMagicLinkRejectsLinkForPreviousEmail(t *testing.T) {
+	service, existUser, _ := newTwoFactorTestService(t)
+	env.AppConfig.MagicLinkEnabled = true
+	existUser.EmailVerified = &enum.FALSE
+
+	service.magicLinkStorage = &fakeMagicLinkStorage{links: map[string]*model.MagicLink{
+		helper.HashToken("magic-token"): {UserID: existUser.UserID, Email: existUser.Email},
+	}}
+	existUser.Email = "new@example.com"
+
+	_, err := service.LoginWithMagicLink(&auth.MagicLinkLoginDto{Token: "magic-token"})
+	if err == nil {
+		t.Fatal("LoginWithMagicLink() accepted a link sent to the previous email")
+	}
+
+	updatedUser, _ := service.storage.GetUserByID(existUser.UserID)
+	if *updatedUser.EmailVerified {
+		t.Fatal("LoginWithMagicLink() verified the new email")
+	}
+}
+
+func TestLoginWithWebAuthnRespectsIPLock(t *testing.T) {
+	service, _, _ := newTwoFactorTestService(t)
+
+	lockedUntil := time.Now().Add(time.Hour)
+	_, err := service.loginAttemptStorage.IncreaseLoginFailure(enum.LoginAttemptType.IPAddress, "203.0.113.7", time.Now(), lockedUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempt, _ := service.loginAttemptStorage.GetLoginAttempt(enum.LoginAttemptType.IPAddress, "203.0.113.7")
+	attempt.LockedUntil = &lockedUntil
+
+	input := &auth.WebAuthnLoginDto{}
+	input.Client.IPAddress = "203.0.113.7"
+
+	var apiErr *helper.APIError
+	_, err = service.LoginWithWebAuthn(input)
+	if !errors.As(err, &apiErr) || apiErr.Code != enum.ErrorCodeLocked.IPAddress {
+		t.Fatalf("LoginWithWebAuthn() from a locked ip address: err = %v", err)
+	}
+}
+
+func TestResetPasswordWithTokenRevokesSessions(t *testing.T) {
+	service, existUser, _ := newTwoFactorTestService(t)
+	service.passwordResetStorage = &fakePasswordResetStorage{resets: map[string]*model.PasswordReset{
+		helper.HashToken("reset-token"): {UserID: existUser.UserID},
+	}}
+
+	sessionStorage := service.sessionStorage.(*fakeSessionStorage)
+	sessionStorage.sessions = []*model.Session{
+		{UserID: existUser.UserID, SessionID: "session-1"},
+		{UserID: existUser.UserID, SessionID: "session-2"},
+		{UserID: "user-2", SessionID: "session-3"},
+	}
+
+	input := &auth.ResetPasswordDto{}
+	input.User.Token = "reset-token"
+	input.User.NewPassword = "Correct-Horse-Battery-Staple-42"
+	if _, err := service.ResetPasswordWithToken(input); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sessionID := range []string{"session-1", "session-2"} {
+		if revoked, _ := service.revokedTokenStorage.IsTokenRevoked(sessionID); !revoked {
+			t.Fatalf("ResetPasswordWithToken() left %s alive", sessionID)
+		}
+	}
+	if revoked, _ := service.revokedTokenStorage.IsTokenRevoked("session-3"); revoked {
+		t.Fatal("ResetPasswordWithToken() revoked a session of another user")
+	}
+	if sessions, _ := sessionStorage.GetSessionsByUserID(existUser.UserID); len(sessions) != 0 {
+		t.Fatalf("ResetPasswordWithToken() kept %d sessions", len(sessions))
+	}
+}
+
+func TestGetLoginBackoffDelayWithoutMax(t *testing.T) {
+	loadTestConfig(t)
+	env.AppConfig.LoginBackoffBase = 1
+	env.AppConfig.LoginBackoffMax = 0
+
+	for _, failedCount := range []int64{1, 2, 64, 1 << 40} {
+		delay := getLoginBackoffDelay(failedCount)
+		if delay <= 0 || delay > LOGIN_BACKOFF_DEFAULT_MAX*time.Second {
+			t.Fatalf("getLoginBackoffDelay(%d) = %v, want a delay up to %ds", failedCount, delay, LOGIN_BACKOFF_DEFAULT_MAX)
+		}
+	}
+	if delay := getLoginBackoffDelay(1 << 40); delay != LOGIN_BACKOFF_DEFAULT_MAX*time.Second {
+		t.Fatalf("getLoginBackoffDelay() = %v, want the default cap", delay)
+	}
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"realworld-authentication/dto/auth"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"realworld-authentication/utils"
+	"strings"
+	"time"
+)
+
+// resolveProviderIdentity exchanges the authorization code with github or a registered openid connect provider,
+// codeVerifier and nonce are empty when the authorization request did not use them
+func (s *authService) resolveProviderIdentity(provider, code, codeVerifier, nonce string) (*helper.OIDCIdentity, error) {
+	provider = strings.ToLower(provider)
+	if provider == helper.GITHUB_PROVIDER {
+		tokenResp, err := helper.GetGithubOauthToken(code)
+		if err != nil {
+			return nil, err
+		}
+
+		githubUserInfo, err := helper.GetGithubUserInfo(tokenResp.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		// github only returns the primary email once it is verified
+		return &helper.OIDCIdentity{
+			Provider:      helper.GITHUB_PROVIDER,
+			Subject:       githubUserInfo.ID,
+			Email:         strings.ToLower(githubUserInfo.Email),
+			EmailVerified: true,
+			Username:      githubUserInfo.Login,
+			Name:          githubUserInfo.Name,
+		}, nil
+	}
+
+	oidcProvider, err := s.providerRegistry.GetProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenResp, err := oidcProvider.Exchange(code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return oidcProvider.VerifyIDToken(tokenResp.IDToken, nonce)
+}
+
+// loginWithIdentity only trusts the provider subject, an email match never logs into an account that did not
+// link the provider, otherwise anyone controlling the same address at the provider could take the account over
+func (s *authService) loginWithIdentity(identity *helper.OIDCIdentity, client auth.SessionClientDto) (*entity.SocialLoginResponse, error) {
+	existUser, err := s.storage.GetUserByIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		return s.issueSocialLoginTokens(existUser, client)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("provider email is not verified")
+	}
+
+	providerName := enum.ProviderNameValue(strings.ToUpper(identity.Provider))
+	linkedIdentity := newUserIdentity(identity)
+
+	existUser, err = s.storage.GetUserByEmail(identity.Email)
+	if err == nil {
+		// accounts created by the provider before identities were stored are linked on their next login
+		if existUser.Provider != providerName || findUserIdentity(existUser, identity.Provider) != nil {
+			return nil, errors.New("an account with this email already exists, login and link the provider from your profile")
+		}
+
+		existUser, err = s.storage.AddUserIdentity(existUser.UserID, linkedIdentity)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.issueSocialLoginTokens(existUser, client)
+	}
+
+	newUser := &model.User{
+		UserID:        utils.GenAccountID(),
+		Email:         identity.Email,
+		Username:      identity.Username,
+		Provider:      providerName,
+		Role:          enum.UserRole.User,
+		Status:        enum.UserStatus.Active,
+		EmailVerified: &enum.TRUE,
+		Identities:    []*model.UserIdentity{linkedIdentity},
+	}
+	if newUser.Username == "" {
+		newUser.Username = identity.Name
+	}
+
+	newUser, err = s.storage.CreateUser(newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueSocialLoginTokens(newUser, client)
+}
+
+// issueSocialLoginTokens goes through the same checks as a password login, the provider only replaces the password
+func (s *authService) issueSocialLoginTokens(existUser *model.User, client auth.SessionClientDto) (*entity.SocialLoginResponse, error) {
+	err := s.checkLoginAttempt(enum.LoginAttemptType.Account, existUser.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	userLoginResp, err := s.completeLogin(existUser, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewSocialLoginRespFromLogin(userLoginResp), nil
+}
+
+func (s *authService) GetMyIdentities(userID string) (*entity.IdentityListResponse, error) {
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewIdentityListResponse(existUser.Identities), nil
+}
+
+// StartLinkIdentity starts the same provider login as StartProviderLogin, with state, nonce and PKCE, the state
+// carries the user so the callback links the identity instead of logging in
+func (s *authService) StartLinkIdentity(userID string, input *user.LinkIdentityDto) (*entity.ProviderLoginStartResponse, error) {
+	if userID == "" {
+		return nil, errors.New("missing user id")
+	}
+
+	return s.StartProviderLogin(&auth.ProviderLoginStartDto{
+		Provider:   input.Provider,
+		ReturnPath: input.ReturnPath,
+		LinkUserID: userID,
+	})
+}
+
+func (s *authService) linkIdentity(userID string, identity *helper.OIDCIdentity) (*entity.SocialLoginResponse, error) {
+	linkedUser, err := s.storage.GetUserByIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		if linkedUser.UserID == userID {
+			return nil, errors.New("provider account is already linked")
+		}
+		return nil, errors.New("provider account is linked to another user")
+	}
+
+	_, err = s.storage.AddUserIdentity(userID, newUserIdentity(identity))
+	if err != nil {
+		return nil, fmt.Errorf("could not link %s, unlink the current account first", identity.Provider)
+	}
+
+	s.createIdentityAuditLog(userID, enum.AuditEvent.IdentityLinked, identity.Provider)
+	return &entity.SocialLoginResponse{LinkedProvider: identity.Provider}, nil
+}
+
+// UnlinkIdentity keeps at least one way to login, a user without password cannot remove the last provider
+func (s *authService) UnlinkIdentity(userID, provider string) (*entity.IdentityListResponse, error) {
+	provider = strings.ToLower(provider)
+
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if findUserIdentity(existUser, provider) == nil {
+		return nil, errors.New("provider is not linked")
+	}
+
+	if existUser.HashedPassword == "" && len(existUser.Identities) == 1 {
+		return nil, errors.New("cannot unlink the last login method, set a password first")
+	}
+
+	existUser, err = s.storage.RemoveUserIdentity(userID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	s.createIdentityAuditLog(userID, enum.AuditEvent.IdentityUnlinked, provider)
+	return entity.NewIdentityListResponse(existUser.Identities), nil
+}
+
+func (s *authService) createIdentityAuditLog(userID string, event enum.AuditEventValue, provider string) {
+	_, err := s.auditStorage.CreateAuditLog(&model.AuditLog{
+		UserID: userID,
+		Event:  event,
+		Detail: fmt.Sprintf("provider %s", provider),
+	})
+	if err != nil {
+		log.Printf("create audit log for %s: %v", userID, err)
+	}
+}
+
+func newUserIdentity(identity *helper.OIDCIdentity) *model.UserIdentity {
+	now := time.Now()
+	return &model.UserIdentity{
+		Provider:   identity.Provider,
+		Subject:    identity.Subject,
+		Email:      identity.Email,
+		LinkedTime: &now,
+	}
+}
+
+func findUserIdentity(existUser *model.User, provider string) *model.UserIdentity {
+	for _, identity := range existUser.Identities {
+		if identity.Provider == provider {
+			return identity
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"realworld-authentication/config/env"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+const (
+	// LOGIN_BACKOFF_DEFAULT_MAX is the cap in seconds used when login_backoff_max is not a positive value
+	LOGIN_BACKOFF_DEFAULT_MAX = 60
+	// LOGIN_BACKOFF_MAX_DOUBLINGS keeps the doubling loop short and the delay far from overflowing
+	LOGIN_BACKOFF_MAX_DOUBLINGS = 30
+)
+
+// checkLoginAttempt rejects the login while the account or ip address is locked or still in its back-off delay
+func (s *authService) checkLoginAttempt(attemptType enum.LoginAttemptTypeValue, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	attempt, err := s.loginAttemptStorage.GetLoginAttempt(attemptType, value)
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		lockedErr := helper.NewAPIError(enum.ErrorCodeLocked.Account, "account is temporarily locked, try again later")
+		if attemptType == enum.LoginAttemptType.IPAddress {
+			lockedErr = helper.NewAPIError(enum.ErrorCodeLocked.IPAddress, "too many failed logins from this ip address, try again later")
+		}
+		lockedErr.RetryAfter = attempt.LockedUntil.Sub(now)
+		return lockedErr
+	}
+
+	if attempt.LastFailedTime != nil {
+		retryAfter := attempt.LastFailedTime.Add(getLoginBackoffDelay(attempt.FailedCount)).Sub(now)
+		if retryAfter > 0 {
+			backoffErr := helper.NewAPIError(enum.ErrorCodeTooManyRequests.Login, "too many failed login attempts, try again later")
+			backoffErr.RetryAfter = retryAfter
+			return backoffErr
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts the failure for the ip address and the account when it exists, the lock error is
+// returned when this failure locks the account
+func (s *authService) recordLoginFailure(existUser *model.User, ipAddress string) error {
+	now := time.Now()
+	expiredTime := now.Add(env.AppConfig.LoginFailureWindow * time.Minute)
+	lockedUntil := now.Add(env.AppConfig.LoginLockoutDuration * time.Minute)
+
+	if ipAddress != "" {
+		attempt, err := s.loginAttemptStorage.IncreaseLoginFailure(enum.LoginAttemptType.IPAddress, ipAddress, now, expiredTime)
+		if err != nil {
+			log.Printf("record failed login for ip %s: %v", ipAddress, err)
+		} else if reachLockoutThreshold(attempt, env.AppConfig.LoginIPLockoutThreshold) {
+			_, _ = s.loginAttemptStorage.LockLoginAttempt(enum.LoginAttemptType.IPAddress, ipAddress, lockedUntil, lockedUntil.Add(env.AppConfig.LoginFailureWindow*time.Minute))
+		}
+	}
+
+	if existUser == nil {
+		return nil
+	}
+
+	attempt, err := s.loginAttemptStorage.IncreaseLoginFailure(enum.LoginAttemptType.Account, existUser.UserID, now, expiredTime)
+	if err != nil {
+		log.Printf("record failed login for %s: %v", existUser.UserID, err)
+		return nil
+	}
+
+	if !reachLockoutThreshold(attempt, env.AppConfig.LoginLockoutThreshold) {
+		return nil
+	}
+
+	// the lock only matches once, concurrent failures do not notify the user twice
+	_, err = s.loginAttemptStorage.LockLoginAttempt(enum.LoginAttemptType.Account, existUser.UserID, lockedUntil, lockedUntil.Add(env.AppConfig.LoginFailureWindow*time.Minute))
+	if err == nil {
+		s.notifyAccountLocked(existUser, ipAddress, attempt.FailedCount)
+	}
+
+	lockedErr := helper.NewAPIError(enum.ErrorCodeLocked.Account, "account is temporarily locked, try again later")
+	lockedErr.RetryAfter = lockedUntil.Sub(now)
+	return lockedErr
+}
+
+func (s *authService) notifyAccountLocked(existUser *model.User, ipAddress string, failedCount int64) {
+	_, err := s.auditStorage.CreateAuditLog(&model.AuditLog{
+		UserID:    existUser.UserID,
+		Event:     enum.AuditEvent.AccountLocked,
+		IPAddress: ipAddress,
+		Detail:    fmt.Sprintf("locked after %d failed logins", failedCount),
+	})
+	if err != nil {
+		log.Printf("create audit log for %s: %v", existUser.UserID, err)
+	}
+
+	err = s.notificationService.SendAccountLockedEmail(existUser.Email, env.AppConfig.LoginLockoutDuration*time.Minute)
+	if err != nil {
+		log.Printf("send account locked email to %s: %v", existUser.UserID, err)
+	}
+}
+
+func (s *authService) resetLoginAttempt(userID string) {
+	err := s.loginAttemptStorage.DeleteLoginAttempt(enum.LoginAttemptType.Account, userID)
+	if err != nil {
+		log.Printf("reset failed logins for %s: %v", userID, err)
+	}
+}
+
+func reachLockoutThreshold(attempt *model.LoginAttempt, threshold int64) bool {
+	return threshold > 0 && attempt.FailedCount >= threshold
+}
+
+// getLoginBackoffDelay doubles the wait after every consecutive failure, capped at the configured maximum or
+// LOGIN_BACKOFF_DEFAULT_MAX seconds when no positive maximum is configured
+func getLoginBackoffDelay(failedCount int64) time.Duration {
+	delay := env.AppConfig.LoginBackoffBase * time.Second
+	maxDelay := env.AppConfig.LoginBackoffMax * time.Second
+	if delay <= 0 || failedCount <= 0 {
+		return 0
+	}
+	if maxDelay <= 0 {
+		maxDelay = LOGIN_BACKOFF_DEFAULT_MAX * time.Second
+	}
+
+	doublings := failedCount - 1
+	if doublings > LOGIN_BACKOFF_MAX_DOUBLINGS {
+		doublings = LOGIN_BACKOFF_MAX_DOUBLINGS
+	}
+	for i := int64(0); i < doublings && delay < maxDelay; i++ {
+		if delay > maxDelay/2 {
+			delay = maxDelay
+			break
+		}
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
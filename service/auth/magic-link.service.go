@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/auth"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+// SendMagicLink answers the same way whether the email is registered or throttled, so it cannot be used to
+// find out which emails have an account
+func (s *authService) SendMagicLink(email string) error {
+	if !env.AppConfig.MagicLinkEnabled {
+		return errors.New("magic link login is disabled")
+	}
+
+	existUser, err := s.storage.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	_, err = s.storage.UpdateMagicLinkSentTime(existUser.UserID, now, now.Add(-env.AppConfig.MagicLinkResendIn*time.Minute))
+	if err != nil {
+		log.Printf("magic link for %s is throttled", existUser.UserID)
+		return nil
+	}
+
+	loginToken, err := helper.GenerateRandomToken(MAGIC_LINK_TOKEN_BYTES)
+	if err != nil {
+		return err
+	}
+
+	expiredTime := now.Add(env.AppConfig.MagicLinkExpiredIn * time.Minute)
+	_, err = s.magicLinkStorage.CreateMagicLink(&model.MagicLink{
+		UserID:      existUser.UserID,
+		Email:       existUser.Email,
+		TokenHash:   helper.HashToken(loginToken),
+		ExpiredTime: &expiredTime,
+	})
+	if err != nil {
+		log.Printf("create magic link for %s: %v", existUser.UserID, err)
+		return nil
+	}
+
+	err = s.notificationService.SendMagicLinkEmail(existUser.Email, fmt.Sprintf(env.AppConfig.MagicLinkUrl, loginToken))
+	if err != nil {
+		log.Printf("send magic link email to %s: %v", existUser.UserID, err)
+	}
+
+	return nil
+}
+
+// LoginWithMagicLink consumes the link, opening it proves the user owns the email so it is marked verified
+func (s *authService) LoginWithMagicLink(input *auth.MagicLinkLoginDto) (*entity.UserLoginResponse, error) {
+	if !env.AppConfig.MagicLinkEnabled {
+		return nil, errors.New("magic link login is disabled")
+	}
+
+	err := s.checkLoginAttempt(enum.LoginAttemptType.IPAddress, input.Client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	magicLink, err := s.magicLinkStorage.UseMagicLink(helper.HashToken(input.Token))
+	if err != nil {
+		_ = s.recordLoginFailure(nil, input.Client.IPAddress)
+		return nil, errors.New("magic link is invalid or expired")
+	}
+
+	// the link is a first factor like the password, it does not open a locked account
+	err = s.checkLoginAttempt(enum.LoginAttemptType.Account, magicLink.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	existUser, err := s.storage.GetUserByID(magicLink.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the link proves the address it was sent to, it is void once the user changed the email
+	if magicLink.Email == "" || magicLink.Email != existUser.Email {
+		return nil, errors.New("magic link is invalid or expired")
+	}
+
+	// other pending links of the user are no longer valid
+	err = s.magicLinkStorage.DeleteMagicLinksByUserID(existUser.UserID)
+	if err != nil {
+		log.Printf("delete magic links of %s: %v", existUser.UserID, err)
+	}
+
+	if existUser.EmailVerified == nil || !*existUser.EmailVerified {
+		updateData := &model.User{
+			EmailVerified: &enum.TRUE,
+		}
+		if existUser.Status == enum.UserStatus.Pending {
+			updateData.Status = enum.UserStatus.Active
+		}
+
+		existUser, err = s.storage.UpdateUser(&model.User{
+			ID: existUser.ID,
+		}, updateData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.resetLoginAttempt(existUser.UserID)
+	return s.completeLogin(existUser, input.Client)
+}
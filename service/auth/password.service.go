@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"realworld-authentication/config/env"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+// checkPasswordPolicy returns the failed rules under the invalid password code so the caller can show each of them,
+// the breach count is returned when the password is breached but the policy only warns
+func (s *authService) checkPasswordPolicy(password, username, email string) (int64, error) {
+	failures := helper.ValidatePasswordPolicy(password, username, email)
+
+	// the dataset is a local file, a read error should not block every password change
+	breachCount, err := s.breachedPasswordDataset.Count(password)
+	if err != nil {
+		log.Printf("check breached password: %v", err)
+	}
+	if breachCount < env.AppConfig.BreachedPasswordMinCount || breachCount <= 0 {
+		breachCount = 0
+	}
+	if breachCount > 0 && env.AppConfig.BreachedPasswordAction != enum.BreachedPasswordAction.Warn {
+		failures = append(failures, &helper.PasswordRuleFailure{
+			Rule:    enum.PasswordRule.Breached,
+			Message: fmt.Sprintf("password has appeared %d times in data breaches", breachCount),
+		})
+	}
+
+	if len(failures) > 0 {
+		policyErr := helper.NewAPIError(enum.ErrorCodeInvalid.Password, "password does not meet the password policy")
+		policyErr.Data = entity.NewPasswordPolicyResponse(failures)
+		return 0, policyErr
+	}
+
+	return breachCount, nil
+}
+
+// checkPasswordHistory rejects the current password and the previous ones kept in history, it returns the history
+// to store with the new password where the current password becomes the newest entry
+func (s *authService) checkPasswordHistory(existUser *model.User, newPassword string) ([]string, error) {
+	count := env.AppConfig.PasswordHistoryCount
+	if count <= 0 {
+		return nil, nil
+	}
+
+	var previousPasswords []string
+	if existUser.HashedPassword != "" {
+		previousPasswords = append(previousPasswords, existUser.HashedPassword)
+	}
+	previousPasswords = append(previousPasswords, existUser.PasswordHistory...)
+	if len(previousPasswords) > count {
+		previousPasswords = previousPasswords[:count]
+	}
+
+	for _, hashedPassword := range previousPasswords {
+		if helper.VerifyPassword(hashedPassword, newPassword) {
+			return nil, helper.NewAPIError(enum.ErrorCodeReused.Password, fmt.Sprintf("password must not be one of your last %d passwords", count))
+		}
+	}
+
+	// with the new password as current one, only count - 1 previous passwords are needed
+	if len(previousPasswords) > count-1 {
+		previousPasswords = previousPasswords[:count-1]
+	}
+
+	return previousPasswords, nil
+}
+
+// isPasswordChangeRequired is true when an admin asked for a change or the password is older than the configured
+// age, users created before the change time was tracked are aged from their creation
+func isPasswordChangeRequired(existUser *model.User) bool {
+	if existUser.HashedPassword == "" {
+		return false
+	}
+
+	if existUser.PasswordChangeRequired != nil && *existUser.PasswordChangeRequired {
+		return true
+	}
+
+	if env.AppConfig.PasswordExpiredIn <= 0 {
+		return false
+	}
+
+	changedTime := existUser.PasswordChangedAt
+	if changedTime == nil {
+		changedTime = existUser.CreatedTime
+	}
+	if changedTime == nil {
+		return false
+	}
+
+	return time.Now().After(changedTime.Add(env.AppConfig.PasswordExpiredIn * time.Minute))
+}
+
+// RequirePasswordChange makes the next login of the user return a password change token, the current sessions
+// are revoked so the user has to login again
+func (s *authService) RequirePasswordChange(adminID, userID string) error {
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if existUser.HashedPassword == "" {
+		return errors.New("user has no password to change")
+	}
+
+	_, err = s.storage.UpdateUser(&model.User{
+		ID: existUser.ID,
+	}, &model.User{
+		PasswordChangeRequired: &enum.TRUE,
+	})
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.sessionStorage.GetSessionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		err = s.revokeSession(userID, session.SessionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.auditStorage.CreateAuditLog(&model.AuditLog{
+		UserID: userID,
+		Event:  enum.AuditEvent.PasswordChangeRequired,
+		Detail: fmt.Sprintf("required by %s", adminID),
+	})
+	if err != nil {
+		log.Printf("create audit log for %s: %v", userID, err)
+	}
+
+	return nil
+}
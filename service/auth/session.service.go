@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/auth"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"realworld-authentication/utils"
+	"time"
+)
+
+// issueLoginTokens opens a new session, every session keeps its own refresh token
+func (s *authService) issueLoginTokens(existUserResp *model.User, client auth.SessionClientDto) (*entity.UserLoginResponse, error) {
+	sessionID := utils.GenSessionID()
+	if sessionID == "" {
+		return nil, errors.New("could not generate session id")
+	}
+
+	accessToken, err := helper.GenerateAccessJWT(existUserResp.UserID, sessionID, env.AppConfig.AccessTokenExpiredIn)
+	if err != nil {
+		return nil, err
+	}
+	existUserResp.AccessToken = *accessToken.Token
+
+	refreshToken, err := helper.GenerateSessionJWT(existUserResp.UserID, sessionID, env.AppConfig.RefreshTokenExpiredIn, env.AppConfig.RefreshTokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiredTime := time.Unix(*refreshToken.ExpiredIn, 0)
+	_, err = s.sessionStorage.CreateSession(&model.Session{
+		SessionID:        sessionID,
+		UserID:           existUserResp.UserID,
+		DeviceName:       client.DeviceName,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		RefreshTokenHash: helper.HashToken(*refreshToken.Token),
+		LastUsedTime:     &now,
+		ExpiredTime:      &expiredTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewUserLoginResponse(existUserResp, *refreshToken.Token), nil
+}
+
+// revokeSession deletes the refresh token of the session and rejects its access tokens until they expire
+func (s *authService) revokeSession(userID, sessionID string) error {
+	err := s.revokedTokenStorage.RevokeToken(sessionID, time.Now().Add(env.AppConfig.AccessTokenExpiredIn*time.Minute))
+	if err != nil {
+		return err
+	}
+
+	return s.sessionStorage.DeleteSession(userID, sessionID)
+}
+
+func (s *authService) revokeReusedSession(session *model.Session, ipAddress string) error {
+	err := s.revokeSession(session.UserID, session.SessionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.auditStorage.CreateAuditLog(&model.AuditLog{
+		UserID:    session.UserID,
+		Event:     enum.AuditEvent.RefreshTokenReuse,
+		IPAddress: ipAddress,
+		Detail:    fmt.Sprintf("session %s is revoked", session.SessionID),
+	})
+	if err != nil {
+		log.Printf("create audit log for %s: %v", session.UserID, err)
+	}
+
+	return errors.New("refresh token reuse is detected, please login again")
+}
+
+func (s *authService) GetMySessions(userID, currentSessionID string) (*entity.SessionListResponse, error) {
+	sessions, err := s.sessionStorage.GetSessionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewSessionListResponse(sessions, currentSessionID), nil
+}
+
+func (s *authService) RevokeSession(userID, sessionID string) error {
+	session, err := s.sessionStorage.GetSessionByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("session is not existed")
+	}
+
+	return s.revokeSession(userID, sessionID)
+}
+
+func (s *authService) RevokeOtherSessions(userID, currentSessionID string) error {
+	if currentSessionID == "" {
+		return errors.New("current session is unknown, please login again")
+	}
+
+	sessions, err := s.sessionStorage.GetSessionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	revokedTime := time.Now().Add(env.AppConfig.AccessTokenExpiredIn * time.Minute)
+	for _, session := range sessions {
+		if session.SessionID == currentSessionID {
+			continue
+		}
+
+		err = s.revokedTokenStorage.RevokeToken(session.SessionID, revokedTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.sessionStorage.DeleteOtherSessions(userID, currentSessionID)
+}
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/auth"
+	"realworld-authentication/dto/user"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+const (
+	WEBAUTHN_USER_VERIFICATION_REQUIRED    = "required"
+	WEBAUTHN_USER_VERIFICATION_PREFERRED   = "preferred"
+	WEBAUTHN_USER_VERIFICATION_DISCOURAGED = "discouraged"
+)
+
+func (s *authService) BeginWebAuthnRegistration(userID string) (*entity.WebAuthnRegistrationOptionsResponse, error) {
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.webAuthnCredentialStorage.GetWebAuthnCredentialsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.createWebAuthnChallenge(enum.WebAuthnCeremony.Registration, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(entity.WebAuthnRegistrationOptionsResponse)
+	resp.PublicKey.Challenge = challenge
+	resp.PublicKey.RP.ID = env.AppConfig.WebAuthnRPID
+	resp.PublicKey.RP.Name = env.AppConfig.WebAuthnRPName
+	resp.PublicKey.User.ID = base64.RawURLEncoding.EncodeToString([]byte(existUser.UserID))
+	resp.PublicKey.User.Name = existUser.Email
+	resp.PublicKey.User.DisplayName = existUser.Username
+	resp.PublicKey.Timeout = int64(env.AppConfig.WebAuthnChallengeExpiredIn * time.Minute / time.Millisecond)
+	resp.PublicKey.Attestation = helper.WEBAUTHN_ATTESTATION_NONE
+	resp.PublicKey.ExcludeCredentials = entity.NewWebAuthnCredentialDescriptors(credentials)
+	resp.PublicKey.AuthenticatorSelection.ResidentKey = WEBAUTHN_USER_VERIFICATION_PREFERRED
+	resp.PublicKey.AuthenticatorSelection.UserVerification = WEBAUTHN_USER_VERIFICATION_PREFERRED
+	for _, algorithm := range helper.WebAuthnAlgorithms {
+		resp.PublicKey.PubKeyCredParams = append(resp.PublicKey.PubKeyCredParams, &entity.WebAuthnCredentialParameter{
+			Type: "public-key",
+			Alg:  algorithm,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *authService) FinishWebAuthnRegistration(userID string, input *user.WebAuthnRegisterDto) (*entity.WebAuthnCredentialResponse, error) {
+	clientDataJSON, err := helper.DecodeBase64URL(input.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.New("client data is not valid base64url")
+	}
+
+	attestationObject, err := helper.DecodeBase64URL(input.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.New("attestation object is not valid base64url")
+	}
+
+	challenge, err := s.useWebAuthnChallenge(clientDataJSON, enum.WebAuthnCeremony.Registration)
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge.UserID != userID {
+		return nil, errors.New("webauthn challenge belongs to another user")
+	}
+
+	credential, err := helper.VerifyWebAuthnRegistration(attestationObject, clientDataJSON, challenge.Challenge, false)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(credential.CredentialID)
+	if credentialID != input.Credential.ID {
+		return nil, errors.New("credential id does not match the attested credential")
+	}
+
+	signCount := int64(credential.SignCount)
+	createdCredential, err := s.webAuthnCredentialStorage.CreateWebAuthnCredential(&model.WebAuthnCredential{
+		CredentialID: credentialID,
+		UserID:       userID,
+		Name:         input.Name,
+		PublicKey:    credential.PublicKey,
+		Algorithm:    credential.Algorithm,
+		SignCount:    &signCount,
+		AAGUID:       hex.EncodeToString(credential.AAGUID),
+		Transports:   input.Credential.Response.Transports,
+	})
+	if err != nil {
+		return nil, errors.New("credential is already registered")
+	}
+
+	return entity.NewWebAuthnCredentialResponse(createdCredential), nil
+}
+
+func (s *authService) GetMyWebAuthnCredentials(userID string) (*entity.WebAuthnCredentialListResponse, error) {
+	credentials, err := s.webAuthnCredentialStorage.GetWebAuthnCredentialsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewWebAuthnCredentialListResponse(credentials), nil
+}
+
+// DeleteWebAuthnCredential keeps at least one way to login for users without password or linked provider
+func (s *authService) DeleteWebAuthnCredential(userID, credentialID string) error {
+	existUser, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	credentials, err := s.webAuthnCredentialStorage.GetWebAuthnCredentialsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, credential := range credentials {
+		if credential.CredentialID == credentialID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.New("credential is not existed")
+	}
+
+	if existUser.HashedPassword == "" && len(existUser.Identities) == 0 && len(credentials) == 1 {
+		return errors.New("cannot delete the last login method, set a password first")
+	}
+
+	return s.webAuthnCredentialStorage.DeleteWebAuthnCredential(userID, credentialID)
+}
+
+// BeginWebAuthnLogin starts a passwordless login, without email the authenticator offers its discoverable
+// credentials. Unknown emails get the same answer as users without credentials
+func (s *authService) BeginWebAuthnLogin(input *auth.WebAuthnLoginBeginDto) (*entity.WebAuthnLoginOptionsResponse, error) {
+	credentials := []*model.WebAuthnCredential{}
+	if input.User.Email != "" {
+		existUser, err := s.storage.GetUserByEmail(input.User.Email)
+		if err == nil {
+			credentials, err = s.webAuthnCredentialStorage.GetWebAuthnCredentialsByUserID(existUser.UserID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	challenge, err := s.createWebAuthnChallenge(enum.WebAuthnCeremony.Login, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return newWebAuthnLoginOptions(challenge, credentials, WEBAUTHN_USER_VERIFICATION_REQUIRED), nil
+}
+
+// LoginWithWebAuthn is a first factor like the password, it goes through the same lockout and completeLogin
+// checks. The user is only known once the assertion is verified, so a failed assertion counts for the ip address
+func (s *authService) LoginWithWebAuthn(input *auth.WebAuthnLoginDto) (*entity.UserLoginResponse, error) {
+	err := s.checkLoginAttempt(enum.LoginAttemptType.IPAddress, input.Client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.verifyWebAuthnAssertion(&input.Credential, enum.WebAuthnCeremony.Login, "", true)
+	if err != nil {
+		_ = s.recordLoginFailure(nil, input.Client.IPAddress)
+		return nil, err
+	}
+
+	err = s.checkLoginAttempt(enum.LoginAttemptType.Account, credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	existUser, err := s.storage.GetUserByID(credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.resetLoginAttempt(existUser.UserID)
+	return s.completeLogin(existUser, input.Client)
+}
+
+func (s *authService) BeginWebAuthnTwoFactor(input *auth.WebAuthnTwoFactorBeginDto) (*entity.WebAuthnLoginOptionsResponse, error) {
+	token, err := s.validateTwoFactorChallenge(input.User.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkLoginAttempt(enum.LoginAttemptType.Account, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.webAuthnCredentialStorage.GetWebAuthnCredentialsByUserID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(credentials) == 0 {
+		return nil, errors.New("no security key is registered")
+	}
+
+	challenge, err := s.createWebAuthnChallenge(enum.WebAuthnCeremony.SecondFactor, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWebAuthnLoginOptions(challenge, credentials, WEBAUTHN_USER_VERIFICATION_DISCOURAGED), nil
+}
+
+func (s *authService) LoginWithWebAuthnTwoFactor(input *auth.WebAuthnTwoFactorLoginDto) (*entity.UserLoginResponse, error) {
+	token, err := s.validateTwoFactorChallenge(input.User.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkLoginAttempt(enum.LoginAttemptType.Account, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	existUser, err := s.storage.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.verifyWebAuthnAssertion(&input.Credential, enum.WebAuthnCeremony.SecondFactor, token.UserID, false)
+	if err != nil {
+		if lockedErr := s.recordLoginFailure(existUser, input.Client.IPAddress); lockedErr != nil {
+			return nil, lockedErr
+		}
+		return nil, err
+	}
+
+	err = s.useTwoFactorChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+
+	s.resetLoginAttempt(existUser.UserID)
+	return s.finishLogin(existUser, input.Client)
+}
+
+// verifyWebAuthnAssertion consumes the challenge, verifies the signature with the stored key and moves the sign
+// counter forward, userID is empty for passwordless login where the credential decides the user
+func (s *authService) verifyWebAuthnAssertion(input *auth.WebAuthnAssertionDto, ceremony enum.WebAuthnCeremonyValue, userID string, requireUserVerification bool) (*model.WebAuthnCredential, error) {
+	clientDataJSON, err := helper.DecodeBase64URL(input.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.New("client data is not valid base64url")
+	}
+
+	authenticatorData, err := helper.DecodeBase64URL(input.Response.AuthenticatorData)
+	if err != nil {
+		return nil, errors.New("authenticator data is not valid base64url")
+	}
+
+	signature, err := helper.DecodeBase64URL(input.Response.Signature)
+	if err != nil {
+		return nil, errors.New("signature is not valid base64url")
+	}
+
+	challenge, err := s.useWebAuthnChallenge(clientDataJSON, ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge.UserID != userID {
+		return nil, errors.New("webauthn challenge belongs to another user")
+	}
+
+	credential, err := s.webAuthnCredentialStorage.GetWebAuthnCredentialByID(input.ID)
+	if err != nil {
+		return nil, errors.New("credential is not registered")
+	}
+
+	if userID != "" && credential.UserID != userID {
+		return nil, errors.New("credential belongs to another user")
+	}
+
+	// the user handle of a discoverable credential is the user id given at registration
+	if input.Response.UserHandle != "" {
+		userHandle, err := helper.DecodeBase64URL(input.Response.UserHandle)
+		if err != nil || string(userHandle) != credential.UserID {
+			return nil, errors.New("user handle does not match the credential")
+		}
+	}
+
+	authData, err := helper.VerifyWebAuthnAssertion(credential.PublicKey, authenticatorData, clientDataJSON, signature, challenge.Challenge, requireUserVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	var storedCount int64
+	if credential.SignCount != nil {
+		storedCount = *credential.SignCount
+	}
+
+	if err := helper.ValidateWebAuthnSignCount(uint32(storedCount), authData.SignCount); err != nil {
+		return nil, err
+	}
+
+	credential, err = s.webAuthnCredentialStorage.UpdateWebAuthnSignCount(credential.CredentialID, storedCount, int64(authData.SignCount))
+	if err != nil {
+		return nil, errors.New("credential was used concurrently, please try again")
+	}
+
+	return credential, nil
+}
+
+func (s *authService) createWebAuthnChallenge(ceremony enum.WebAuthnCeremonyValue, userID string) (string, error) {
+	challenge, err := helper.GenerateWebAuthnChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	expiredTime := time.Now().Add(env.AppConfig.WebAuthnChallengeExpiredIn * time.Minute)
+	_, err = s.webAuthnChallengeStorage.CreateWebAuthnChallenge(&model.WebAuthnChallenge{
+		Challenge:   challenge,
+		Ceremony:    ceremony,
+		UserID:      userID,
+		ExpiredTime: &expiredTime,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+func (s *authService) useWebAuthnChallenge(clientDataJSON []byte, ceremony enum.WebAuthnCeremonyValue) (*model.WebAuthnChallenge, error) {
+	clientData, err := helper.ParseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.webAuthnChallengeStorage.UseWebAuthnChallenge(clientData.Challenge, ceremony)
+	if err != nil {
+		return nil, errors.New("webauthn challenge is invalid or expired")
+	}
+
+	return challenge, nil
+}
+
+func newWebAuthnLoginOptions(challenge string, credentials []*model.WebAuthnCredential, userVerification string) *entity.WebAuthnLoginOptionsResponse {
+	resp := new(entity.WebAuthnLoginOptionsResponse)
+	resp.PublicKey.Challenge = challenge
+	resp.PublicKey.Timeout = int64(env.AppConfig.WebAuthnChallengeExpiredIn * time.Minute / time.Millisecond)
+	resp.PublicKey.RPID = env.AppConfig.WebAuthnRPID
+	resp.PublicKey.AllowCredentials = entity.NewWebAuthnCredentialDescriptors(credentials)
+	resp.PublicKey.UserVerification = userVerification
+
+	return resp
+}
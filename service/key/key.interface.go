@@ -0,0 +1,13 @@
+package key
+
+import (
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+)
+
+type SigningKeyStorage interface {
+	CreateSigningKey(data *model.SigningKey) (*model.SigningKey, error)
+	GetSigningKeys() ([]*model.SigningKey, error)
+	GetSigningKeyByKeyID(keyID string) (*model.SigningKey, error)
+	UpdateSigningKeyStatus(keyID string, currentStatus enum.SigningKeyStatusValue, data *model.SigningKey) (*model.SigningKey, error)
+}
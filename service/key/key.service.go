@@ -0,0 +1,236 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/admin"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+type keyService struct {
+	storage SigningKeyStorage
+}
+
+func NewKeyService(storage SigningKeyStorage) *keyService {
+	return &keyService{
+		storage: storage,
+	}
+}
+
+// LoadKeySet replaces helper.AccessTokenKeySet with the keys stored in the signing_keys collection,
+// the keys from config are imported on first start
+func (s *keyService) LoadKeySet() error {
+	keys, err := s.storage.GetSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		if err := s.importConfigKeys(); err != nil {
+			return err
+		}
+
+		if keys, err = s.storage.GetSigningKeys(); err != nil {
+			return err
+		}
+	}
+
+	var (
+		active     *helper.SigningKey
+		verifyOnly []*helper.SigningKey
+	)
+	for _, key := range keys {
+		signingKey, err := s.decryptSigningKey(key)
+		if err != nil {
+			log.Printf("skip signing key %s: %v", key.KeyID, err)
+			continue
+		}
+
+		// keys are sorted by activation, the latest active key signs while a promotion is in progress
+		if key.Status == enum.SigningKeyStatus.Active && active == nil {
+			active = signingKey
+			continue
+		}
+		verifyOnly = append(verifyOnly, signingKey)
+	}
+
+	if active == nil {
+		return errors.New("no active signing key")
+	}
+
+	helper.AccessTokenKeySet.SetKeys(active, verifyOnly...)
+	return nil
+}
+
+// WatchKeySet reloads the keyset periodically so keys created or promoted on another instance are picked up
+func (s *keyService) WatchKeySet(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.LoadKeySet(); err != nil {
+			log.Printf("reload signing keys: %v", err)
+		}
+	}
+}
+
+func (s *keyService) GetJWKS() *helper.JWKS {
+	return helper.AccessTokenKeySet.PublicJWKS()
+}
+
+func (s *keyService) GetSigningKeys() (*entity.SigningKeyListResponse, error) {
+	keys, err := s.storage.GetSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewSigningKeyListResponse(keys), nil
+}
+
+// CreateSigningKey stores a pending key, it is published in the JWKS right away so downstream caches
+// know it before it is promoted
+func (s *keyService) CreateSigningKey(input *admin.CreateSigningKeyDto) (*entity.SigningKeyResponse, error) {
+	signingKey, err := helper.GenerateSigningKey(input.SigningKey.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.storeSigningKey(signingKey, &model.SigningKey{
+		Status: enum.SigningKeyStatus.Pending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.LoadKeySet(); err != nil {
+		log.Printf("reload signing keys: %v", err)
+	}
+
+	return entity.NewSigningKeyResponse(key), nil
+}
+
+// PromoteSigningKey makes a pending key active, the previous active key keeps verifying until
+// every access token it signed has expired and is then retired
+func (s *keyService) PromoteSigningKey(keyID string) (*entity.SigningKeyResponse, error) {
+	key, err := s.storage.GetSigningKeyByKeyID(keyID)
+	if err != nil {
+		return nil, errors.New("signing key is not existed")
+	}
+	if key.Status != enum.SigningKeyStatus.Pending {
+		return nil, errors.New("only pending signing key can be promoted")
+	}
+
+	keys, err := s.storage.GetSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key, err = s.storage.UpdateSigningKeyStatus(keyID, enum.SigningKeyStatus.Pending, &model.SigningKey{
+		Status:        enum.SigningKeyStatus.Active,
+		ActivatedTime: &now,
+	})
+	if err != nil {
+		return nil, errors.New("signing key is not pending")
+	}
+
+	expiredTime := getRetiredKeyExpiredTime(now)
+	for _, previous := range keys {
+		if previous.Status != enum.SigningKeyStatus.Active || previous.KeyID == keyID {
+			continue
+		}
+
+		_, err := s.storage.UpdateSigningKeyStatus(previous.KeyID, enum.SigningKeyStatus.Active, &model.SigningKey{
+			Status:          enum.SigningKeyStatus.Inactive,
+			DeactivatedTime: &now,
+			ExpiredTime:     &expiredTime,
+		})
+		if err != nil {
+			log.Printf("deactivate signing key %s: %v", previous.KeyID, err)
+		}
+	}
+
+	if err := s.LoadKeySet(); err != nil {
+		log.Printf("reload signing keys: %v", err)
+	}
+
+	return entity.NewSigningKeyResponse(key), nil
+}
+
+// importConfigKeys stores the key configured by access_token_signing_method as the first active key,
+// a legacy hmac key kept for verification is retired after the maximum token lifetime
+func (s *keyService) importConfigKeys() error {
+	configKeySet := &helper.KeySet{}
+	if err := helper.LoadConfigKeySet(configKeySet); err != nil {
+		return err
+	}
+
+	active, err := configKeySet.ActiveKey()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := s.storeSigningKey(active, &model.SigningKey{
+		Status:        enum.SigningKeyStatus.Active,
+		ActivatedTime: &now,
+	}); err != nil {
+		// another instance may have imported the same key concurrently
+		log.Printf("import signing key %s: %v", active.KeyID, err)
+	}
+
+	expiredTime := getRetiredKeyExpiredTime(now)
+	for _, key := range configKeySet.Keys() {
+		if key.KeyID == active.KeyID {
+			continue
+		}
+
+		if _, err := s.storeSigningKey(key, &model.SigningKey{
+			Status:          enum.SigningKeyStatus.Inactive,
+			DeactivatedTime: &now,
+			ExpiredTime:     &expiredTime,
+		}); err != nil {
+			log.Printf("import signing key %s: %v", key.KeyID, err)
+		}
+	}
+
+	return nil
+}
+
+// getRetiredKeyExpiredTime keeps a retired key for the access token lifetime after the next refresh, other
+// instances keep signing with it until they reload the key set
+func getRetiredKeyExpiredTime(now time.Time) time.Time {
+	return now.Add((env.AppConfig.SigningKeyRefreshIn + env.AppConfig.AccessTokenExpiredIn) * time.Minute)
+}
+
+func (s *keyService) storeSigningKey(signingKey *helper.SigningKey, data *model.SigningKey) (*model.SigningKey, error) {
+	material, err := helper.MarshalSigningKey(signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := helper.EncryptString(material, env.AppConfig.SigningKeyEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt signing key: %w", err)
+	}
+
+	data.KeyID = signingKey.KeyID
+	data.Algorithm = signingKey.Method.Alg()
+	data.PrivateKey = encryptedKey
+	return s.storage.CreateSigningKey(data)
+}
+
+func (s *keyService) decryptSigningKey(key *model.SigningKey) (*helper.SigningKey, error) {
+	material, err := helper.DecryptString(key.PrivateKey, env.AppConfig.SigningKeyEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt signing key: %w", err)
+	}
+
+	return helper.ParseSigningKey(key.Algorithm, material)
+}
@@ -0,0 +1,15 @@
+package notification
+
+import "log"
+
+// logNotifier prints messages instead of delivering them, it is meant for local development
+type logNotifier struct{}
+
+func NewLogNotifier() *logNotifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Send(to, subject, htmlBody string) error {
+	log.Printf("notification to %s\nsubject: %s\n%s", to, subject, htmlBody)
+	return nil
+}
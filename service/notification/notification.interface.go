@@ -0,0 +1,5 @@
+package notification
+
+type Notifier interface {
+	Send(to, subject, htmlBody string) error
+}
@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"realworld-authentication/config/env"
+	"realworld-authentication/model/enum"
+	"time"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var (
+	templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+	subjects = map[enum.NotificationTypeValue]string{
+		enum.NotificationType.ResetPassword: "Reset your password",
+		enum.NotificationType.VerifyEmail:   "Verify your email address",
+		enum.NotificationType.MagicLink:     "Your sign in link",
+		enum.NotificationType.AccountLocked: "Your account has been locked",
+	}
+)
+
+type notificationService struct {
+	notifier Notifier
+}
+
+func NewNotificationService(notifier Notifier) *notificationService {
+	return &notificationService{
+		notifier: notifier,
+	}
+}
+
+// NewNotifier returns the notifier matched with the configured driver, log notifier is the default
+func NewNotifier(driver enum.NotificationDriverValue) Notifier {
+	switch driver {
+	case enum.NotificationDriver.SMTP:
+		return NewSMTPNotifier()
+	default:
+		return NewLogNotifier()
+	}
+}
+
+func (s *notificationService) SendResetPasswordEmail(email, resetLink string) error {
+	return s.send(email, enum.NotificationType.ResetPassword, map[string]interface{}{
+		"Email":     email,
+		"ResetLink": resetLink,
+		"ExpiredIn": int64(env.AppConfig.ResetPasswordTokenExpiredIn),
+	})
+}
+
+func (s *notificationService) SendVerificationEmail(email, verifyLink string) error {
+	return s.send(email, enum.NotificationType.VerifyEmail, map[string]interface{}{
+		"Email":      email,
+		"VerifyLink": verifyLink,
+	})
+}
+
+func (s *notificationService) SendMagicLinkEmail(email, loginLink string) error {
+	return s.send(email, enum.NotificationType.MagicLink, map[string]interface{}{
+		"Email":     email,
+		"LoginLink": loginLink,
+		"ExpiredIn": int64(env.AppConfig.MagicLinkExpiredIn),
+	})
+}
+
+func (s *notificationService) SendAccountLockedEmail(email string, lockedFor time.Duration) error {
+	return s.send(email, enum.NotificationType.AccountLocked, map[string]interface{}{
+		"Email":     email,
+		"LockedFor": int64(lockedFor.Minutes()),
+	})
+}
+
+func (s *notificationService) send(to string, notificationType enum.NotificationTypeValue, data interface{}) error {
+	var body bytes.Buffer
+	err := templates.ExecuteTemplate(&body, string(notificationType)+".html", data)
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(to, subjects[notificationType], body.String())
+}
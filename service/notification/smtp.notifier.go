@@ -0,0 +1,42 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"realworld-authentication/config/env"
+)
+
+type smtpNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPNotifier() *smtpNotifier {
+	n := &smtpNotifier{
+		addr: fmt.Sprintf("%s:%d", env.AppConfig.SMTPHost, env.AppConfig.SMTPPort),
+		from: env.AppConfig.SMTPFrom,
+	}
+
+	// local smtp catchers accept mail without authentication
+	if env.AppConfig.SMTPUsername != "" {
+		n.auth = smtp.PlainAuth("", env.AppConfig.SMTPUsername, env.AppConfig.SMTPPassword, env.AppConfig.SMTPHost)
+	}
+
+	return n
+}
+
+func (n *smtpNotifier) Send(to, subject, htmlBody string) error {
+	var msg bytes.Buffer
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", n.from))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject)))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(htmlBody)
+
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{to}, msg.Bytes())
+}
@@ -0,0 +1,17 @@
+package oauth
+
+import (
+	"realworld-authentication/model"
+)
+
+type ClientStorage interface {
+	CreateClient(data *model.Client) (*model.Client, error)
+	GetClientByID(clientID string) (*model.Client, error)
+	GetClients() ([]*model.Client, error)
+	DeleteClient(clientID string) error
+}
+
+type AuthorizationCodeStorage interface {
+	CreateAuthorizationCode(data *model.AuthorizationCode) (*model.AuthorizationCode, error)
+	UseAuthorizationCode(codeHash string) (*model.AuthorizationCode, error)
+}
@@ -0,0 +1,455 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/url"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/admin"
+	"realworld-authentication/dto/oauth"
+	"realworld-authentication/entity"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	auth_service "realworld-authentication/service/auth"
+	"realworld-authentication/utils"
+	"strings"
+	"time"
+)
+
+const (
+	AUTHORIZATION_CODE_BYTES = 32
+	CLIENT_SECRET_BYTES      = 32
+	RESPONSE_TYPE_CODE       = "code"
+	TOKEN_TYPE_BEARER        = "Bearer"
+)
+
+type oauthService struct {
+	clientStorage            ClientStorage
+	authorizationCodeStorage AuthorizationCodeStorage
+	authStorage              auth_service.AuthStorage
+}
+
+func NewOAuthService(clientStorage ClientStorage, authorizationCodeStorage AuthorizationCodeStorage, authStorage auth_service.AuthStorage) *oauthService {
+	return &oauthService{
+		clientStorage:            clientStorage,
+		authorizationCodeStorage: authorizationCodeStorage,
+		authStorage:              authStorage,
+	}
+}
+
+func (s *oauthService) GetOpenIDConfiguration() *entity.OpenIDConfigurationResponse {
+	issuer := strings.TrimSuffix(env.AppConfig.OIDCIssuer, "/")
+
+	// without an asymmetric key no id token can be issued, so no algorithm is advertised
+	algorithms := []string{}
+	if signingKey, err := helper.IDTokenSigningKey(); err == nil {
+		algorithms = append(algorithms, signingKey.Method.Alg())
+	}
+
+	return &entity.OpenIDConfigurationResponse{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/api/oauth/authorize",
+		TokenEndpoint:                     issuer + "/api/oauth/token",
+		UserinfoEndpoint:                  issuer + "/api/oauth/userinfo",
+		JwksURI:                           issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{RESPONSE_TYPE_CODE},
+		GrantTypesSupported:               []string{string(enum.OAuthGrantType.AuthorizationCode), string(enum.OAuthGrantType.ClientCredentials)},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algorithms,
+		ScopesSupported:                   []string{string(enum.OAuthScope.OpenID), string(enum.OAuthScope.Email), string(enum.OAuthScope.Profile)},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email", "email_verified", "preferred_username", "name"},
+		CodeChallengeMethodsSupported:     []string{helper.PKCE_METHOD_S256},
+	}
+}
+
+// ValidateAuthorizeClient checks the client and its redirect uri, errors found here must not be sent to
+// the redirect uri because it may belong to an attacker
+func (s *oauthService) ValidateAuthorizeClient(input *oauth.AuthorizeDto) (*model.Client, error) {
+	client, err := s.clientStorage.GetClientByID(input.ClientID)
+	if err != nil {
+		return nil, errors.New("client is not registered")
+	}
+
+	for _, redirectURI := range client.RedirectURIs {
+		if redirectURI == input.RedirectURI {
+			return client, nil
+		}
+	}
+
+	return nil, errors.New("redirect uri is not registered for the client")
+}
+
+// Authorize issues an authorization code for the logged in user, failures of the request itself are
+// reported through the redirect uri as RFC 6749 section 4.1.2.1 requires
+func (s *oauthService) Authorize(input *oauth.AuthorizeDto) (*entity.AuthorizeResponse, error) {
+	client, err := s.ValidateAuthorizeClient(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isGrantTypeAllowed(client, enum.OAuthGrantType.AuthorizationCode) {
+		return buildAuthorizeRedirect(input.RedirectURI, url.Values{
+			"error": {string(enum.OAuthErrorCode.UnauthorizedClient)},
+		}, input.State)
+	}
+
+	code, err := s.createAuthorizationCode(client, input)
+	if err != nil {
+		var oauthErr *helper.OAuthError
+		if !errors.As(err, &oauthErr) {
+			return nil, err
+		}
+
+		return buildAuthorizeRedirect(input.RedirectURI, url.Values{
+			"error":             {string(oauthErr.Code)},
+			"error_description": {oauthErr.Description},
+		}, input.State)
+	}
+
+	return buildAuthorizeRedirect(input.RedirectURI, url.Values{
+		"code": {code},
+	}, input.State)
+}
+
+func (s *oauthService) createAuthorizationCode(client *model.Client, input *oauth.AuthorizeDto) (string, error) {
+	if input.ResponseType != RESPONSE_TYPE_CODE {
+		return "", helper.NewOAuthError(enum.OAuthErrorCode.UnsupportedResponseType, "only the authorization code flow is supported")
+	}
+
+	if input.CodeChallenge == "" || input.CodeChallengeMethod != helper.PKCE_METHOD_S256 {
+		return "", helper.NewOAuthError(enum.OAuthErrorCode.InvalidRequest, "code_challenge with method S256 is required")
+	}
+
+	if !isScopeAllowed(client, input.Scope) {
+		return "", helper.NewOAuthError(enum.OAuthErrorCode.InvalidScope, "requested scope is not allowed for the client")
+	}
+
+	if helper.HasScope(input.Scope, enum.OAuthScope.OpenID) {
+		if _, err := helper.IDTokenSigningKey(); err != nil {
+			return "", helper.NewOAuthError(enum.OAuthErrorCode.InvalidScope, "openid scope is not available, the server has no asymmetric signing key")
+		}
+	}
+
+	user, err := s.authStorage.GetUserByID(input.UserID)
+	if err != nil || user.Status != enum.UserStatus.Active {
+		return "", helper.NewOAuthError(enum.OAuthErrorCode.AccessDenied, "user cannot authorize the client")
+	}
+
+	code, err := helper.GenerateRandomToken(AUTHORIZATION_CODE_BYTES)
+	if err != nil {
+		return "", err
+	}
+
+	expiredTime := time.Now().Add(env.AppConfig.OIDCAuthorizationCodeExpiredIn * time.Minute)
+	_, err = s.authorizationCodeStorage.CreateAuthorizationCode(&model.AuthorizationCode{
+		CodeHash:            helper.HashToken(code),
+		ClientID:            client.ClientID,
+		UserID:              user.UserID,
+		RedirectURI:         input.RedirectURI,
+		Scope:               input.Scope,
+		Nonce:               input.Nonce,
+		CodeChallenge:       input.CodeChallenge,
+		CodeChallengeMethod: input.CodeChallengeMethod,
+		ExpiredTime:         &expiredTime,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (s *oauthService) ExchangeToken(input *oauth.TokenDto) (*entity.OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(input.ClientID, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	grantType := enum.OAuthGrantTypeValue(input.GrantType)
+	if grantType != enum.OAuthGrantType.AuthorizationCode && grantType != enum.OAuthGrantType.ClientCredentials {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.UnsupportedGrantType, "grant type is not supported")
+	}
+
+	if !isGrantTypeAllowed(client, grantType) {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.UnauthorizedClient, "grant type is not allowed for the client")
+	}
+
+	if grantType == enum.OAuthGrantType.ClientCredentials {
+		return s.exchangeClientCredentials(client, input)
+	}
+
+	return s.exchangeAuthorizationCode(client, input)
+}
+
+func (s *oauthService) exchangeAuthorizationCode(client *model.Client, input *oauth.TokenDto) (*entity.OAuthTokenResponse, error) {
+	if input.Code == "" || input.CodeVerifier == "" {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidRequest, "code and code_verifier are required")
+	}
+
+	authorizationCode, err := s.authorizationCodeStorage.UseAuthorizationCode(helper.HashToken(input.Code))
+	if err != nil {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidGrant, "authorization code is invalid or expired")
+	}
+
+	if authorizationCode.ClientID != client.ClientID || authorizationCode.RedirectURI != input.RedirectURI {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidGrant, "authorization code was issued to another client")
+	}
+
+	if !helper.VerifyPKCEChallenge(input.CodeVerifier, authorizationCode.CodeChallenge, authorizationCode.CodeChallengeMethod) {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidGrant, "code verifier does not match")
+	}
+
+	user, err := s.authStorage.GetUserByID(authorizationCode.UserID)
+	if err != nil || user.Status != enum.UserStatus.Active {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidGrant, "user is not active")
+	}
+
+	accessToken, err := helper.GenerateOAuthAccessJWT(user.UserID, client.ClientID, authorizationCode.Scope, env.AppConfig.AccessTokenExpiredIn)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.OAuthTokenResponse{
+		AccessToken: *accessToken.Token,
+		TokenType:   TOKEN_TYPE_BEARER,
+		ExpiresIn:   int64((env.AppConfig.AccessTokenExpiredIn * time.Minute).Seconds()),
+		Scope:       authorizationCode.Scope,
+	}
+
+	if helper.HasScope(authorizationCode.Scope, enum.OAuthScope.OpenID) {
+		idToken, err := helper.GenerateIDToken(buildIDTokenClaims(user, client, authorizationCode), env.AppConfig.AccessTokenExpiredIn)
+		if err != nil {
+			return nil, err
+		}
+		resp.IDToken = idToken
+	}
+
+	return resp, nil
+}
+
+// exchangeClientCredentials issues a token representing the client, without a requested scope it receives
+// every scope registered for the client
+func (s *oauthService) exchangeClientCredentials(client *model.Client, input *oauth.TokenDto) (*entity.OAuthTokenResponse, error) {
+	if client.Public != nil && *client.Public {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.UnauthorizedClient, "public client cannot use client credentials")
+	}
+
+	// openid is about a user and has no meaning for the client itself, so it is left out of the default scopes
+	scope := input.Scope
+	if scope == "" {
+		var scopes []string
+		for _, clientScope := range client.Scopes {
+			if clientScope != string(enum.OAuthScope.OpenID) {
+				scopes = append(scopes, clientScope)
+			}
+		}
+		scope = strings.Join(scopes, " ")
+	}
+	if !isScopeAllowed(client, scope) || helper.HasScope(scope, enum.OAuthScope.OpenID) {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidScope, "requested scope is not allowed for the client")
+	}
+
+	accessToken, err := helper.GenerateClientAccessJWT(client.ClientID, scope, env.AppConfig.AccessTokenExpiredIn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthTokenResponse{
+		AccessToken: *accessToken.Token,
+		TokenType:   TOKEN_TYPE_BEARER,
+		ExpiresIn:   int64((env.AppConfig.AccessTokenExpiredIn * time.Minute).Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+func (s *oauthService) GetUserInfo(userID, scope string) (*entity.UserInfoResponse, error) {
+	if !helper.HasScope(scope, enum.OAuthScope.OpenID) {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InsufficientScope, "access token was not granted the openid scope")
+	}
+
+	user, err := s.authStorage.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user is not existed")
+	}
+
+	resp := &entity.UserInfoResponse{
+		Sub: user.UserID,
+	}
+	if helper.HasScope(scope, enum.OAuthScope.Email) {
+		resp.Email = user.Email
+		resp.EmailVerified = isEmailVerified(user)
+	}
+	if helper.HasScope(scope, enum.OAuthScope.Profile) {
+		resp.PreferredUsername = user.Username
+		resp.Name = user.Username
+	}
+
+	return resp, nil
+}
+
+// CreateClient returns the client secret once, only its hash is stored
+func (s *oauthService) CreateClient(input *admin.CreateClientDto) (*entity.ClientResponse, error) {
+	client := &model.Client{
+		ClientID:     utils.GenClientID(),
+		Name:         input.Client.Name,
+		RedirectURIs: input.Client.RedirectURIs,
+		Scopes:       input.Client.Scopes,
+		Public:       &enum.FALSE,
+	}
+	if client.ClientID == "" {
+		return nil, errors.New("could not generate client id")
+	}
+
+	for _, grantType := range input.Client.GrantTypes {
+		client.GrantTypes = append(client.GrantTypes, enum.OAuthGrantTypeValue(grantType))
+	}
+
+	if isGrantTypeAllowed(client, enum.OAuthGrantType.AuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return nil, errors.New("authorization code client requires redirect uris")
+	}
+	if isGrantTypeAllowed(client, enum.OAuthGrantType.ClientCredentials) && input.Client.Public {
+		return nil, errors.New("client credentials client cannot be public")
+	}
+
+	for _, redirectURI := range client.RedirectURIs {
+		if u, err := url.Parse(redirectURI); err != nil || u.Fragment != "" || !u.IsAbs() {
+			return nil, errors.New("redirect uri must be an absolute url without fragment")
+		}
+	}
+
+	clientSecret := ""
+	if input.Client.Public {
+		client.Public = &enum.TRUE
+	} else {
+		secret, err := helper.GenerateRandomToken(CLIENT_SECRET_BYTES)
+		if err != nil {
+			return nil, err
+		}
+		clientSecret = secret
+		client.ClientSecretHash = helper.HashToken(secret)
+	}
+
+	client, err := s.clientStorage.CreateClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewClientResponse(client, clientSecret), nil
+}
+
+func (s *oauthService) GetClients() (*entity.ClientListResponse, error) {
+	clients, err := s.clientStorage.GetClients()
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewClientListResponse(clients), nil
+}
+
+func (s *oauthService) DeleteClient(clientID string) error {
+	if _, err := s.clientStorage.GetClientByID(clientID); err != nil {
+		return errors.New("client is not existed")
+	}
+
+	return s.clientStorage.DeleteClient(clientID)
+}
+
+// authenticateClient requires the secret of confidential clients, public clients rely on PKCE only
+func (s *oauthService) authenticateClient(clientID, clientSecret string) (*model.Client, error) {
+	if clientID == "" {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidClient, "client authentication is required")
+	}
+
+	client, err := s.clientStorage.GetClientByID(clientID)
+	if err != nil {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidClient, "client authentication failed")
+	}
+
+	if client.Public != nil && *client.Public {
+		return client, nil
+	}
+
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(helper.HashToken(clientSecret)), []byte(client.ClientSecretHash)) != 1 {
+		return nil, helper.NewOAuthError(enum.OAuthErrorCode.InvalidClient, "client authentication failed")
+	}
+
+	return client, nil
+}
+
+func buildIDTokenClaims(user *model.User, client *model.Client, authorizationCode *model.AuthorizationCode) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss": strings.TrimSuffix(env.AppConfig.OIDCIssuer, "/"),
+		"sub": user.UserID,
+		"aud": client.ClientID,
+	}
+	if authorizationCode.Nonce != "" {
+		claims["nonce"] = authorizationCode.Nonce
+	}
+	if helper.HasScope(authorizationCode.Scope, enum.OAuthScope.Email) {
+		claims["email"] = user.Email
+		claims["email_verified"] = *isEmailVerified(user)
+	}
+	if helper.HasScope(authorizationCode.Scope, enum.OAuthScope.Profile) {
+		claims["preferred_username"] = user.Username
+		claims["name"] = user.Username
+	}
+
+	return claims
+}
+
+func buildAuthorizeRedirect(redirectURI string, values url.Values, state string) (*entity.AuthorizeResponse, error) {
+	if state != "" {
+		values.Set("state", state)
+	}
+
+	location, err := helper.AppendQuery(redirectURI, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.AuthorizeResponse{
+		RedirectURI: location,
+	}, nil
+}
+
+func isScopeAllowed(client *model.Client, scope string) bool {
+	for _, requested := range helper.ParseScope(scope) {
+		allowed := false
+		for _, clientScope := range client.Scopes {
+			if clientScope == requested {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isGrantTypeAllowed(client *model.Client, grantType enum.OAuthGrantTypeValue) bool {
+	if len(client.GrantTypes) == 0 {
+		return grantType == enum.OAuthGrantType.AuthorizationCode
+	}
+
+	for _, allowed := range client.GrantTypes {
+		if allowed == grantType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEmailVerified(user *model.User) *bool {
+	if user.EmailVerified != nil && *user.EmailVerified {
+		return &enum.TRUE
+	}
+
+	return &enum.FALSE
+}
@@ -0,0 +1,42 @@
+package oauth
+
+import (
+	"os"
+	"path/filepath"
+	"realworld-authentication/config/env"
+	"realworld-authentication/dto/oauth"
+	"realworld-authentication/helper"
+	"realworld-authentication/model"
+	"realworld-authentication/model/enum"
+	"testing"
+)
+
+const testConfig = `
+access_token_key: test-access-token-key
+access_token_expired_in: 15
+`
+
+func TestClientCredentialsDefaultScopeLeavesOutOpenID(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.LoadConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+	helper.AccessTokenKeySet.SetKeys(helper.NewHMACSigningKey(env.AppConfig.AccessTokenKey))
+
+	service := NewOAuthService(nil, nil, nil)
+	client := &model.Client{
+		ClientID: "client-1",
+		Scopes:   []string{string(enum.OAuthScope.OpenID), "reports:read"},
+	}
+
+	resp, err := service.exchangeClientCredentials(client, &oauth.TokenDto{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Scope != "reports:read" {
+		t.Fatalf("exchangeClientCredentials() scope = %q, want %q", resp.Scope, "reports:read")
+	}
+}
@@ -3,9 +3,13 @@ package utils
 import gonanoid "github.com/matoous/go-nanoid/v2"
 
 const (
-	ACCOUNT          = "ACCOUNT"
-	ACCOUNT_LENGTH   = 6
-	STRING_TO_GEN_ID = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	ACCOUNT              = "ACCOUNT"
+	ACCOUNT_LENGTH       = 6
+	RECOVERY_CODE_LENGTH = 10
+	SESSION_LENGTH       = 16
+	CLIENT_LENGTH        = 16
+	API_KEY_LENGTH       = 16
+	STRING_TO_GEN_ID     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 )
 
 func GenNanoID(alphabet string, length int) string {
@@ -21,3 +25,40 @@ func GenAccountID() string {
 	genResult := "ACC" + GenNanoID(STRING_TO_GEN_ID, ACCOUNT_LENGTH)
 	return genResult
 }
+
+func GenSessionID() string {
+	id := GenNanoID(STRING_TO_GEN_ID, SESSION_LENGTH)
+	if id == "" {
+		return ""
+	}
+
+	return "SES" + id
+}
+
+func GenClientID() string {
+	id := GenNanoID(STRING_TO_GEN_ID, CLIENT_LENGTH)
+	if id == "" {
+		return ""
+	}
+
+	return "CLI" + id
+}
+
+func GenAPIKeyID() string {
+	id := GenNanoID(STRING_TO_GEN_ID, API_KEY_LENGTH)
+	if id == "" {
+		return ""
+	}
+
+	return "AKY" + id
+}
+
+// GenRecoveryCode returns a code formatted as XXXXX-XXXXX
+func GenRecoveryCode() string {
+	code := GenNanoID(STRING_TO_GEN_ID, RECOVERY_CODE_LENGTH)
+	if len(code) != RECOVERY_CODE_LENGTH {
+		return ""
+	}
+
+	return code[:RECOVERY_CODE_LENGTH/2] + "-" + code[RECOVERY_CODE_LENGTH/2:]
+}
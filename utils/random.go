@@ -1,20 +0,0 @@
-package utils
-
-import (
-	"math/rand"
-	"strings"
-)
-
-const (
-	charset         = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	PASSWORD_LENGTH = 8
-)
-
-func RandomString(n int) string {
-	sb := strings.Builder{}
-	sb.Grow(n)
-	for i := 0; i < n; i++ {
-		sb.WriteByte(charset[rand.Intn(len(charset))])
-	}
-	return sb.String()
-}
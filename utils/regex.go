@@ -3,41 +3,26 @@ package utils
 import "regexp"
 
 /*
-	Username must be:
-		+ Include - or _
-		+ Length must be in [5,12]
-
+Username must be:
+  - Only letters, numbers, - or _
+  - Length must be in [5,12]
 */
 func ValidateUsername(username string) bool {
-	const regex = "[A-Za-z-_]{5,12}"
+	const regex = "^[A-Za-z0-9_-]{5,12}$"
 
 	ok, _ := regexp.MatchString(regex, username)
 	return ok
 }
 
 /*
-	Email must be: xxx@gmail.com
-		+ Pattern before '@' must be 1 character
-		+ Pattern after '@' must be have length in range [2,6]
-		+ Pattern after '.' must be have length in range [2,3]
+Email must be: xxx@mail.example.com
+  - Pattern before '@' must be at least 1 character
+  - Domain labels are separated by '.'
+  - Pattern after the last '.' must be at least 2 letters
 */
 func ValidateEmail(email string) bool {
-	const regex = "(\\w+)@(\\w{2,6})\\.(\\w{2,3})"
+	const regex = "^[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\\.[A-Za-z0-9-]+)*\\.[A-Za-z]{2,}$"
 
 	ok, _ := regexp.MatchString(regex, email)
 	return ok
 }
-
-/*
-	Password must be:
-		+ At least 8 character long
-		+ At least 1 lowercase and uppercase letter
-		+ At least 2 numbers
-		+ At least 1 special character
-*/
-func ValidatePassword(password string) bool {
-	const regex = "^[a_zA_Z@#$\\d].{8,}$"
-
-	ok, _ := regexp.MatchString(regex, password)
-	return ok
-}